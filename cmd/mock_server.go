@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ellis-vester/lb-scrape/internal/mockserver"
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+var MockAddr string
+var MockListsPath string
+var MockTooManyRequests int
+var MockRetryAfter time.Duration
+var MockServerErrors int
+var MockLatency time.Duration
+var MockMalformed []string
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Serve a mock Letterboxd for offline development.",
+	Long: `Serve a small set of Letterboxd list, film and user pages from embedded
+			fixtures, optionally failing with 429s, 500s, slow responses and
+			malformed pages. Point scrape-lists at it with --base-url.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		server := mockserver.New(mockserver.Failures{
+			TooManyRequests: MockTooManyRequests,
+			RetryAfter:      MockRetryAfter,
+			ServerErrors:    MockServerErrors,
+			Latency:         MockLatency,
+			Malformed:       MockMalformed,
+		})
+
+		listener, err := net.Listen("tcp", MockAddr)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		baseURL := "http://" + listener.Addr().String()
+
+		urls := []string{}
+		for _, list := range server.Lists() {
+			url, err := lb.ParseListURL(list)
+			if err != nil {
+				fmt.Println("Oh no!", err)
+				os.Exit(1)
+			}
+			urls = append(urls, url.String())
+		}
+
+		if MockListsPath != "" {
+			err = os.WriteFile(MockListsPath, []byte(strings.Join(urls, "\n")+"\n"), 0644)
+			if err != nil {
+				fmt.Println("Oh no!", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Println("Serving mock Letterboxd at " + baseURL + " with the lists:")
+		for _, url := range urls {
+			fmt.Println("  " + url)
+		}
+		usage := "lbs scrape-lists --no-cache --base-url " + baseURL
+		if MockListsPath != "" {
+			usage += " --lists-path " + MockListsPath
+		}
+		fmt.Println("Scrape it with: " + usage)
+
+		err = http.Serve(listener, server)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mockServerCmd)
+
+	mockServerCmd.PersistentFlags().StringVarP(
+		&MockAddr,
+		"addr",
+		"a",
+		"127.0.0.1:8080",
+		"The address to listen on.")
+
+	mockServerCmd.PersistentFlags().StringVarP(
+		&MockListsPath,
+		"lists-path",
+		"l",
+		"",
+		"If set, write the urls of the mock lists to this file for scrape-lists to read.")
+
+	mockServerCmd.PersistentFlags().IntVar(
+		&MockTooManyRequests,
+		"too-many-requests",
+		0,
+		"The number of requests for each page that respond with a 429.")
+
+	mockServerCmd.PersistentFlags().DurationVar(
+		&MockRetryAfter,
+		"retry-after",
+		0,
+		"The Retry-After header to send with 429s, rounded to seconds.")
+
+	mockServerCmd.PersistentFlags().IntVar(
+		&MockServerErrors,
+		"server-errors",
+		0,
+		"The number of requests for each page, after any 429s, that respond with a 500.")
+
+	mockServerCmd.PersistentFlags().DurationVar(
+		&MockLatency,
+		"latency",
+		0,
+		"How long to delay every response.")
+
+	mockServerCmd.PersistentFlags().StringSliceVar(
+		&MockMalformed,
+		"malformed",
+		nil,
+		"Path prefixes to serve a malformed page for, such as /film/parasite-2019/.")
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ellis-vester/lb-scrape/files"
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+	"github.com/ellis-vester/lb-scrape/scraper"
+)
+
+var ReparseSource string
+var ReparseListsPath string
+var ReparseOutputDir string
+var ReparseBaseURL string
+var ReparseMaxPages int
+var ReparseWorkers int
+var ReparseScoring string
+var ReparseTopN int
+var ReparseConsensusSize int
+var ReparseNoCommunity bool
+
+var reparseCmd = &cobra.Command{
+	Use:   "reparse",
+	Short: "Re-run the parsers over stored pages.",
+	Long: `Scrape and aggregate the provided letterboxd lists from pages stored by
+			--cache-dir, --record or --warc instead of Letterboxd, regenerating
+			the CSV files with the current parsers and reporting every list and
+			film that no longer parses to failures.csv.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if ReparseTopN < 1 {
+			fmt.Println("Oh no!", "--top-n must be at least 1")
+			os.Exit(1)
+		}
+
+		scoring, err := scraper.ParseScoring(ReparseScoring)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		baseURL, err := lb.ParseBaseURL(ReparseBaseURL)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		fetcher, err := storedFetcher(ReparseSource)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		lists, err := files.GetFilmListUrls(ReparseListsPath)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		lists, err = scraper.ResolveLists(fetcher, lists, baseURL)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		urls := []string{}
+		for _, list := range lists {
+			urls = append(urls, list.On(baseURL))
+		}
+
+		aggregation := scraper.Aggregate(context.Background(), fetcher, urls, scraper.AggregateOptions{
+			MaxPages:      ReparseMaxPages,
+			Workers:       ReparseWorkers,
+			Scoring:       scoring,
+			TopN:          ReparseTopN,
+			ConsensusSize: ReparseConsensusSize,
+			BaseURL:       baseURL,
+			NoCommunity:   ReparseNoCommunity,
+		})
+
+		err = files.WriteOutputsToCsv(
+			ReparseOutputDir,
+			aggregation.Films,
+			aggregation.Directors,
+			aggregation.People,
+			aggregation.Lists,
+			aggregation.Owners)
+		if err == nil {
+			err = files.WriteFailuresToCsv(aggregation.Failures, filepath.Join(ReparseOutputDir, "failures.csv"))
+		}
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		for _, warning := range aggregation.Warnings {
+			fmt.Println("Warning", warning.URL+":", warning.Err)
+		}
+
+		for _, failure := range aggregation.Failures {
+			fmt.Println("Failed", failure.URL+":", failure.Err)
+		}
+
+		fmt.Printf("Reparsed %d lists and %d films with %d failures.\n",
+			len(aggregation.Lists),
+			len(aggregation.Films),
+			len(aggregation.Failures))
+
+		if len(aggregation.Failures) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// storedFetcher returns a fetcher serving the pages stored at source, which is
+// either a cache or --record directory or a --warc file.
+func storedFetcher(source string) (scraper.Fetcher, error) {
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return scraper.ReplayFetcher{Dir: source}, nil
+	}
+
+	return scraper.OpenWARCArchive(source)
+}
+
+func init() {
+	rootCmd.AddCommand(reparseCmd)
+
+	reparseCmd.PersistentFlags().StringVar(
+		&ReparseSource,
+		"source",
+		defaultCacheDir(),
+		"The cache or --record directory, or --warc file, to read stored pages from.")
+
+	reparseCmd.PersistentFlags().StringVarP(
+		&ReparseListsPath,
+		"lists-path",
+		"l",
+		"./lists.txt",
+		"The path to the file containing the lists to reparse.")
+
+	reparseCmd.PersistentFlags().StringVarP(
+		&ReparseOutputDir,
+		"output-dir",
+		"o",
+		".",
+		"The directory to output the CSV files to.")
+
+	reparseCmd.PersistentFlags().StringVar(
+		&ReparseBaseURL,
+		"base-url",
+		lb.DefaultBaseURL,
+		"The site the stored pages were scraped from.")
+
+	reparseCmd.PersistentFlags().IntVarP(
+		&ReparseMaxPages,
+		"max-pages",
+		"m",
+		0,
+		"The most pages of each list to reparse, or 0 for all of them.")
+
+	reparseCmd.PersistentFlags().IntVarP(
+		&ReparseWorkers,
+		"workers",
+		"w",
+		4,
+		"The number of films to reparse at once.")
+
+	reparseCmd.PersistentFlags().StringVarP(
+		&ReparseScoring,
+		"scoring",
+		"s",
+		string(scraper.ScoreInclusions),
+		"How to score films across lists: inclusions, borda, reciprocal, top-n or rating.")
+
+	reparseCmd.PersistentFlags().IntVar(
+		&ReparseTopN,
+		"top-n",
+		10,
+		"The number of top positions in each list that count towards --scoring top-n.")
+
+	reparseCmd.PersistentFlags().IntVar(
+		&ReparseConsensusSize,
+		"consensus-size",
+		10,
+		"The number of top scoring films owners.csv compares each owner's films with.")
+
+	reparseCmd.PersistentFlags().BoolVar(
+		&ReparseNoCommunity,
+		"no-community",
+		false,
+		"Skip parsing each film's rating histogram and stats.")
+}
@@ -1,18 +1,29 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ellis-vester/lb-scrape/scraper"
 )
 
 var Version string = "v0.1.0"
 
+var SelectorsPath string
+
 var rootCmd = &cobra.Command{
 	Use:     "lbs",
 	Short:   "A tool for scraping and aggregating data from letterboxd.com.",
 	Long:    `.`,
 	Version: Version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		loadSelectors(cmd)
+	},
 }
 
 func Execute() {
@@ -21,3 +32,38 @@ func Execute() {
 		os.Exit(1)
 	}
 }
+
+// loadSelectors has the parsers use the --selectors file, exiting if it's
+// invalid. A missing file is only an error if --selectors was given.
+func loadSelectors(cmd *cobra.Command) {
+
+	selectors, err := scraper.LoadSelectors(SelectorsPath)
+	if errors.Is(err, fs.ErrNotExist) && !cmd.Flags().Changed("selectors") {
+		return
+	}
+	if err == nil {
+		err = scraper.SetSelectors(selectors)
+	}
+	if err != nil {
+		fmt.Println("Oh no!", err)
+		fmt.Println("Fix or remove", SelectorsPath+", or run lbs selectors --defaults to start a new one.")
+		os.Exit(1)
+	}
+}
+
+func defaultSelectorsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".lbs-selectors.json"
+	}
+
+	return filepath.Join(dir, "lbs", "selectors.json")
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(
+		&SelectorsPath,
+		"selectors",
+		defaultSelectorsPath(),
+		"The JSON file overriding the CSS selectors pages are parsed with. See lbs selectors.")
+}
@@ -2,17 +2,45 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ellis-vester/lb-scrape/internal/tui"
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+	"github.com/ellis-vester/lb-scrape/scraper"
 )
 
 var ListsPath string
 var OutputDir string
 var PollInterval int
+var MaxPages int
+var CacheDir string
+var CacheTTL time.Duration
+var NoCache bool
+var MaxAttempts int
+var RetryDelay time.Duration
+var RetryMaxDelay time.Duration
+var Rate float64
+var Burst int
+var Workers int
+var Adaptive bool
+var MinRate float64
+var MaxRate float64
+var Scoring string
+var TopN int
+var ConsensusSize int
+var BaseURL string
+var RecordDir string
+var ReplayDir string
+var WARCPath string
+var Timeout time.Duration
+var NoCommunity bool
 
 var scrapeListsCmd = &cobra.Command{
 	Use:   "scrape-lists",
@@ -21,15 +49,215 @@ var scrapeListsCmd = &cobra.Command{
 			outputting the results to two CSV files.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		model := tui.NewScrapeListsModel(ListsPath, OutputDir, PollInterval)
+		if cmd.Flags().Changed("poll-interval") {
+			if cmd.Flags().Changed("rate") {
+				fmt.Println("Oh no!", "--poll-interval and --rate can't be used together")
+				os.Exit(1)
+			}
 
-		if _, err := tea.NewProgram(model).Run(); err != nil {
+			Rate = 0
+			if PollInterval > 0 {
+				Rate = 1 / float64(PollInterval)
+			}
+		}
+
+		if Adaptive {
+			if MinRate <= 0 || MinRate > MaxRate {
+				fmt.Println("Oh no!", "--min-rate must be more than 0 and no more than --max-rate")
+				os.Exit(1)
+			}
+
+			Rate = math.Min(math.Max(Rate, MinRate), MaxRate)
+		}
+
+		if TopN < 1 {
+			fmt.Println("Oh no!", "--top-n must be at least 1")
+			os.Exit(1)
+		}
+
+		scoring, err := scraper.ParseScoring(Scoring)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		baseURL, err := lb.ParseBaseURL(BaseURL)
+		if err != nil {
 			fmt.Println("Oh no!", err)
 			os.Exit(1)
 		}
+
+		if RecordDir != "" && ReplayDir != "" {
+			fmt.Println("Oh no!", "--record and --replay can't be used together")
+			os.Exit(1)
+		}
+
+		var file *os.File
+
+		// exit closes the WARC file before exiting with code, or with 1 if the
+		// file couldn't be closed, as the archive may then be incomplete.
+		exit := func(code int) {
+			if file != nil {
+				err := file.Close()
+				if err != nil {
+					fmt.Println("Oh no!", err)
+					code = 1
+				}
+			}
+
+			os.Exit(code)
+		}
+
+		var warc *scraper.WARCWriter
+		if WARCPath != "" {
+			file, err = os.Create(WARCPath)
+			if err != nil {
+				fmt.Println("Oh no!", err)
+				os.Exit(1)
+			}
+
+			warc, err = scraper.NewWARCWriter(file, strings.HasSuffix(WARCPath, ".gz"), "lb-scrape/"+Version)
+			if err != nil {
+				fmt.Println("Oh no!", err)
+				exit(1)
+			}
+		}
+
+		fetcher, retrier, limiter, cache, err := newFetcher(warc)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			exit(1)
+		}
+
+		model := tui.NewScrapeListsModel(
+			fetcher,
+			retrier,
+			limiter,
+			ListsPath,
+			OutputDir,
+			scraper.AggregateOptions{
+				MaxPages:      MaxPages,
+				Workers:       Workers,
+				Scoring:       scoring,
+				TopN:          TopN,
+				ConsensusSize: ConsensusSize,
+				NoCommunity:   NoCommunity,
+				BaseURL:       baseURL,
+			})
+
+		result, err := tea.NewProgram(model).Run()
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			exit(1)
+		}
+
+		var summary tui.ScrapeListsModel
+		switch result := result.(type) {
+		case tui.ScrapeListsModel:
+			summary = result
+		case *tui.ScrapeListsModel:
+			summary = *result
+		}
+
+		fmt.Println(summary.Summary())
+		if cache != nil {
+			if errs := cache.WriteErrors(); len(errs) > 0 {
+				fmt.Printf("Warning: %d pages couldn't be cached: %v\n", len(errs), errs[0])
+			}
+		}
+		if summary.Err() != nil {
+			exit(1)
+		}
+
+		exit(0)
 	},
 }
 
+// newFetcher builds the chain of fetchers the scraper goes through, from the
+// recorder and cache down to the live site, returning it along with the
+// fetcher that records retries, the limiter setting the request rate and the
+// cache, which keeps the errors writing to it. When replaying a recording or
+// WARC archive there is no retrier, limiter or cache, nor is there a cache with
+// --no-cache. If
+// warc isn't nil every page the scraper gets is written to it, whether from the
+// site, the cache or the replay, so that the archive holds every page parsed.
+func newFetcher(warc *scraper.WARCWriter) (scraper.Fetcher, *scraper.RetryFetcher, *scraper.Limiter, *scraper.CacheFetcher, error) {
+
+	archive := func(fetcher scraper.Fetcher) scraper.Fetcher {
+		if warc == nil {
+			return fetcher
+		}
+		return &scraper.WARCFetcher{Fetcher: fetcher, Writer: warc}
+	}
+
+	if ReplayDir != "" {
+		info, err := os.Stat(ReplayDir)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		if info.IsDir() {
+			return archive(scraper.ReplayFetcher{Dir: ReplayDir}), nil, nil, nil, nil
+		}
+
+		replay, err := scraper.OpenWARCArchive(ReplayDir)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		return archive(replay), nil, nil, nil, nil
+	}
+
+	var fetcher scraper.Fetcher = scraper.NewHTTPFetcher(Timeout)
+
+	limiter := scraper.NewLimiter(Rate, Burst)
+
+	if Adaptive {
+		fetcher = scraper.NewAdaptiveFetcher(fetcher, limiter, MinRate, MaxRate)
+	}
+
+	fetcher = &scraper.LimitedFetcher{
+		Fetcher: fetcher,
+		Limiter: limiter,
+	}
+
+	retrier := &scraper.RetryFetcher{
+		Fetcher:     fetcher,
+		MaxAttempts: MaxAttempts,
+		BaseDelay:   RetryDelay,
+		MaxDelay:    RetryMaxDelay,
+	}
+	fetcher = retrier
+
+	var cache *scraper.CacheFetcher
+	if !NoCache {
+		cache = &scraper.CacheFetcher{
+			Fetcher: fetcher,
+			Dir:     CacheDir,
+			TTL:     CacheTTL,
+		}
+		fetcher = cache
+	}
+
+	if RecordDir != "" {
+		fetcher = &scraper.RecordFetcher{
+			Fetcher: fetcher,
+			Dir:     RecordDir,
+		}
+	}
+
+	return archive(fetcher), retrier, limiter, cache, nil
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ".lbs-cache"
+	}
+
+	return filepath.Join(dir, "lbs")
+}
+
 func init() {
 	rootCmd.AddCommand(scrapeListsCmd)
 
@@ -52,5 +280,143 @@ func init() {
 		"poll-interval",
 		"p",
 		5,
-		"The seconds to wait between requests to Letterboxd.")
+		"The seconds to wait between requests to Letterboxd, or 0 for no delay.")
+	scrapeListsCmd.PersistentFlags().MarkDeprecated(
+		"poll-interval",
+		"use --rate instead")
+
+	scrapeListsCmd.PersistentFlags().IntVarP(
+		&MaxPages,
+		"max-pages",
+		"m",
+		0,
+		"The maximum number of pages to scrape from each list, or 0 for no limit.")
+
+	scrapeListsCmd.PersistentFlags().StringVar(
+		&CacheDir,
+		"cache-dir",
+		defaultCacheDir(),
+		"The directory to cache fetched pages in.")
+
+	scrapeListsCmd.PersistentFlags().DurationVar(
+		&CacheTTL,
+		"cache-ttl",
+		24*time.Hour,
+		"How long cached pages are used before being fetched again, or 0 to never expire.")
+
+	scrapeListsCmd.PersistentFlags().BoolVar(
+		&NoCache,
+		"no-cache",
+		false,
+		"Fetch every page from Letterboxd without reading or writing the cache.")
+
+	scrapeListsCmd.PersistentFlags().DurationVar(
+		&Timeout,
+		"timeout",
+		scraper.DefaultTimeout,
+		"How long to wait for Letterboxd to respond to a request before retrying it, or 0 to wait forever.")
+
+	scrapeListsCmd.PersistentFlags().IntVar(
+		&MaxAttempts,
+		"max-attempts",
+		4,
+		"The maximum number of times to request a page before giving up.")
+
+	scrapeListsCmd.PersistentFlags().DurationVar(
+		&RetryDelay,
+		"retry-delay",
+		2*time.Second,
+		"The delay before the first retry, doubled for each further retry.")
+
+	scrapeListsCmd.PersistentFlags().DurationVar(
+		&RetryMaxDelay,
+		"retry-max-delay",
+		time.Minute,
+		"The longest delay between retries, unless Letterboxd asks for longer.")
+
+	scrapeListsCmd.PersistentFlags().Float64Var(
+		&Rate,
+		"rate",
+		0.2,
+		"The average number of requests per second to make to Letterboxd, or 0 for no limit.")
+
+	scrapeListsCmd.PersistentFlags().IntVar(
+		&Burst,
+		"burst",
+		1,
+		"The number of requests that may be made at once before --rate applies.")
+
+	scrapeListsCmd.PersistentFlags().IntVarP(
+		&Workers,
+		"workers",
+		"w",
+		4,
+		"The number of films to scrape concurrently.")
+
+	scrapeListsCmd.PersistentFlags().BoolVar(
+		&Adaptive,
+		"adaptive",
+		false,
+		"Adjust the request rate between --min-rate and --max-rate as Letterboxd responds, starting at --rate kept within them.")
+
+	scrapeListsCmd.PersistentFlags().Float64Var(
+		&MinRate,
+		"min-rate",
+		0.1,
+		"The lowest requests per second --adaptive will slow down to.")
+
+	scrapeListsCmd.PersistentFlags().Float64Var(
+		&MaxRate,
+		"max-rate",
+		2,
+		"The highest requests per second --adaptive will speed up to.")
+
+	scrapeListsCmd.PersistentFlags().StringVarP(
+		&Scoring,
+		"scoring",
+		"s",
+		string(scraper.ScoreInclusions),
+		"How to score films across lists: inclusions, borda, reciprocal, top-n or rating.")
+
+	scrapeListsCmd.PersistentFlags().IntVar(
+		&TopN,
+		"top-n",
+		10,
+		"The number of top positions in each list that count towards --scoring top-n.")
+
+	scrapeListsCmd.PersistentFlags().IntVar(
+		&ConsensusSize,
+		"consensus-size",
+		10,
+		"The number of top scoring films owners.csv compares each owner's films with.")
+
+	scrapeListsCmd.PersistentFlags().BoolVar(
+		&NoCommunity,
+		"no-community",
+		false,
+		"Skip scraping each film's rating histogram and stats, making one request per film instead of three.")
+
+	scrapeListsCmd.PersistentFlags().StringVar(
+		&BaseURL,
+		"base-url",
+		lb.DefaultBaseURL,
+		"The site to scrape Letterboxd's pages from, such as a local mirror or test server.")
+
+	scrapeListsCmd.PersistentFlags().StringVar(
+		&RecordDir,
+		"record",
+		"",
+		"If set, record every page fetched, with its url, status and headers, to this directory.")
+
+	scrapeListsCmd.PersistentFlags().StringVar(
+		&ReplayDir,
+		"replay",
+		"",
+		"If set, fetch every page from a directory recorded with --record, or a WARC file written with --warc, instead of Letterboxd.")
+
+	scrapeListsCmd.PersistentFlags().StringVar(
+		&WARCPath,
+		"warc",
+		"",
+		"If set, write every HTTP exchange with Letterboxd to this WARC file, gzipped if it ends in .gz.")
 }
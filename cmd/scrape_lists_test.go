@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ellis-vester/lb-scrape/scraper"
+)
+
+func TestNewFetcher_ArchivesPagesServedFromCache(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>Parasite</html>"))
+	}))
+	defer server.Close()
+
+	defer func(cacheDir string, noCache bool, rate float64) {
+		CacheDir, NoCache, Rate = cacheDir, noCache, rate
+	}(CacheDir, NoCache, Rate)
+	CacheDir, NoCache, Rate = t.TempDir(), false, 0
+
+	url := server.URL + "/film/parasite/"
+
+	warm, _, _, _, err := newFetcher(nil)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	_, err = warm.Fetch(url)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	server.Close()
+
+	archived := bytes.Buffer{}
+	writer, err := scraper.NewWARCWriter(&archived, false, "lb-scrape test")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	fetcher, _, _, _, err := newFetcher(writer)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	_, err = fetcher.Fetch(url)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	archive, err := scraper.ReadWARCArchive(&archived)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	got, err := archive.Fetch(url)
+	if err != nil || string(got.Body) != "<html>Parasite</html>" {
+		t.Errorf("got %v, want the cached page", err)
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ellis-vester/lb-scrape/scraper"
+)
+
+var SelectorsDefaults bool
+
+var selectorsCmd = &cobra.Command{
+	Use:   "selectors",
+	Short: "Print the CSS selectors pages are parsed with.",
+	Long: `Print the CSS selectors pages are parsed with as JSON, after applying
+			the --selectors file. Save the output to the --selectors file and
+			edit the selectors that no longer match Letterboxd's pages to fix
+			parsing without waiting for a new release. Only the version and the
+			selectors being changed need to be kept.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// The defaults are printed to replace a --selectors file that's
+		// stale or invalid, so it isn't loaded for them.
+		if !SelectorsDefaults {
+			loadSelectors(cmd)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		selectors := scraper.CurrentSelectors()
+		if SelectorsDefaults {
+			selectors = scraper.DefaultSelectors()
+		}
+
+		data, err := json.MarshalIndent(selectors, "", "\t")
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(string(data))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selectorsCmd)
+
+	selectorsCmd.PersistentFlags().BoolVar(
+		&SelectorsDefaults,
+		"defaults",
+		false,
+		"Print the built in selectors, ignoring the --selectors file.")
+}
@@ -2,12 +2,29 @@ package files
 
 import (
 	"encoding/csv"
+	"errors"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	lb "github.com/ellis-vester/lb-scrape/letterboxd"
 )
 
+// WriteOutputsToCsv writes the films, directors, people, lists, owners and list
+// memberships of an aggregation to their CSV files in dir.
+func WriteOutputsToCsv(dir string, films []lb.Film, directors []lb.Director, people []lb.Person, lists []lb.List, owners []lb.Owner) error {
+	return errors.Join(
+		WriteFilmsToCsv(films, filepath.Join(dir, "films.csv")),
+		WriteDirectorsToCsv(directors, filepath.Join(dir, "directors.csv")),
+		WritePeopleToCsv(people, filepath.Join(dir, "people.csv")),
+		WriteListsToCsv(lists, filepath.Join(dir, "lists.csv")),
+		WriteOwnersToCsv(owners, filepath.Join(dir, "owners.csv")),
+		WriteMembershipsToCsv(films, filepath.Join(dir, "memberships.csv")))
+}
+
 func WriteFilmsToCsv(films []lb.Film, path string) (err error) {
 	file, err := os.Create(path)
 	if err != nil {
@@ -15,7 +32,10 @@ func WriteFilmsToCsv(films []lb.Film, path string) (err error) {
 	}
 
 	defer func() {
-		err = file.Close()
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
 	}()
 
 	writer := csv.NewWriter(file)
@@ -23,21 +43,99 @@ func WriteFilmsToCsv(films []lb.Film, path string) (err error) {
 		"Title",
 		"Director",
 		"Year",
-		"Rating",
 		"Inclusions",
-		"Link"})
+		"Owner Ratings",
+		"Mean Owner Rating",
+		"Median Owner Rating",
+		"Min Owner Rating",
+		"Max Owner Rating",
+		"Score",
+		"Best Position",
+		"Link",
+		"Original Title",
+		"Runtime",
+		"Genres",
+		"Themes",
+		"Countries",
+		"Primary Language",
+		"Spoken Languages",
+		"Studios",
+		"Cast",
+		"Writers",
+		"Cinematography",
+		"Editors",
+		"Composers",
+		"Producers",
+		"Average Rating",
+		"Rating Count",
+		"½★",
+		"★",
+		"★½",
+		"★★",
+		"★★½",
+		"★★★",
+		"★★★½",
+		"★★★★",
+		"★★★★½",
+		"★★★★★",
+		"Watches",
+		"Lists",
+		"Likes",
+		"Letterboxd ID",
+		"Slug",
+		"TMDB ID",
+		"TMDB Type",
+		"IMDb ID"})
 	if err != nil {
 		return err
 	}
 
 	for _, film := range films {
-		err = writer.Write([]string{
+		row := []string{
 			film.Title,
-			film.Director,
+			joinNames(film.Directors),
 			strconv.Itoa(film.Year),
-			strconv.Itoa(int(film.Rating)),
 			strconv.Itoa(film.Inclusions),
-			film.Link})
+			strconv.Itoa(film.OwnerRatings.Count),
+			formatOptionalFloat(film.OwnerRatings.Mean),
+			formatOptionalFloat(film.OwnerRatings.Median),
+			formatRating(film.OwnerRatings.Min),
+			formatRating(film.OwnerRatings.Max),
+			strconv.FormatFloat(film.Score, 'f', -1, 64),
+			formatOptionalInt(film.BestPosition),
+			film.Link,
+			film.OriginalTitle,
+			formatOptionalInt(film.Runtime),
+			strings.Join(film.Genres, multiValueSeparator),
+			strings.Join(film.Themes, multiValueSeparator),
+			strings.Join(film.Countries, multiValueSeparator),
+			film.PrimaryLanguage,
+			strings.Join(film.SpokenLanguages, multiValueSeparator),
+			strings.Join(film.Studios, multiValueSeparator),
+			joinCast(film.Cast),
+			joinCrew(film.Crew, "writer"),
+			joinCrew(film.Crew, "cinematography"),
+			joinCrew(film.Crew, "editor"),
+			joinCrew(film.Crew, "composer"),
+			joinCrew(film.Crew, "producer"),
+			formatOptionalFloat(film.Community.Average),
+			strconv.Itoa(film.Community.Count)}
+
+		for _, count := range film.Community.Histogram {
+			row = append(row, strconv.Itoa(count))
+		}
+
+		row = append(row,
+			strconv.Itoa(film.Stats.Watches),
+			strconv.Itoa(film.Stats.Lists),
+			strconv.Itoa(film.Stats.Likes),
+			formatOptionalInt(film.LetterboxdID),
+			film.Slug,
+			formatOptionalInt(film.TmdbID),
+			film.TmdbType,
+			film.ImdbID)
+
+		err = writer.Write(row)
 		if err != nil {
 			return err
 		}
@@ -45,7 +143,7 @@ func WriteFilmsToCsv(films []lb.Film, path string) (err error) {
 
 	writer.Flush()
 
-	return err
+	return writer.Error()
 }
 
 func WriteDirectorsToCsv(directors []lb.Director, path string) (err error) {
@@ -55,17 +153,20 @@ func WriteDirectorsToCsv(directors []lb.Director, path string) (err error) {
 	}
 
 	defer func() {
-		err = file.Close()
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
 	}()
 
 	writer := csv.NewWriter(file)
-	err = writer.Write([]string{"Director", "Inclusions"})
+	err = writer.Write([]string{"Director", "Slug", "Inclusions"})
 	if err != nil {
 		return err
 	}
 
 	for _, director := range directors {
-		err = writer.Write([]string{director.Name, strconv.Itoa(director.Inclusions)})
+		err = writer.Write([]string{director.Name, director.Slug, strconv.Itoa(director.Inclusions)})
 		if err != nil {
 			return err
 		}
@@ -73,5 +174,338 @@ func WriteDirectorsToCsv(directors []lb.Director, path string) (err error) {
 
 	writer.Flush()
 
-	return err
+	return writer.Error()
+}
+
+func WritePeopleToCsv(people []lb.Person, path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{"Role", "Name", "Slug", "Inclusions"})
+	if err != nil {
+		return err
+	}
+
+	for _, person := range people {
+		err = writer.Write([]string{person.Role, person.Name, person.Slug, strconv.Itoa(person.Inclusions)})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func WriteOwnersToCsv(owners []lb.Owner, path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{
+		"Owner",
+		"Name",
+		"Lists",
+		"Films",
+		"Rated Films",
+		"Mean Rating",
+		"Median Rating",
+		"Consensus Overlap"})
+	if err != nil {
+		return err
+	}
+
+	for _, owner := range owners {
+		err = writer.Write([]string{
+			owner.Name,
+			owner.DisplayName,
+			strconv.Itoa(owner.Lists),
+			strconv.Itoa(owner.Films),
+			strconv.Itoa(owner.Ratings.Count),
+			formatOptionalFloat(owner.Ratings.Mean),
+			formatOptionalFloat(owner.Ratings.Median),
+			strconv.Itoa(owner.ConsensusOverlap)})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func WriteListsToCsv(lists []lb.List, path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{
+		"Url",
+		"Title",
+		"Owner",
+		"Owner Name",
+		"Description",
+		"Tags",
+		"Published",
+		"Updated",
+		"Likes",
+		"Comments",
+		"Entry Count",
+		"Entries Scraped",
+		"Pages",
+		"Ranked"})
+	if err != nil {
+		return err
+	}
+
+	for _, list := range lists {
+		err = writer.Write([]string{
+			list.URL,
+			list.Title,
+			list.Owner,
+			list.OwnerName,
+			list.Description,
+			strings.Join(list.Tags, multiValueSeparator),
+			formatOptionalTime(list.Published),
+			formatOptionalTime(list.Updated),
+			strconv.Itoa(list.Likes),
+			strconv.Itoa(list.Comments),
+			formatOptionalInt(list.EntryCount),
+			strconv.Itoa(len(list.Entries)),
+			strconv.Itoa(list.Pages),
+			strconv.FormatBool(list.Ranked)})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteMembershipsToCsv writes a row for every list including every film, so
+// that a film's inclusions and score can be traced back to the lists.
+func WriteMembershipsToCsv(films []lb.Film, path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{
+		"Film Link",
+		"Film Title",
+		"List Url",
+		"List Title",
+		"List Owner",
+		"Position",
+		"Ranked",
+		"Rating"})
+	if err != nil {
+		return err
+	}
+
+	for _, film := range films {
+		for _, inclusion := range film.Lists {
+			err = writer.Write([]string{
+				film.Link,
+				film.Title,
+				inclusion.ListURL,
+				inclusion.ListTitle,
+				inclusion.Owner,
+				strconv.Itoa(inclusion.Position),
+				strconv.FormatBool(inclusion.Ranked),
+				formatRating(inclusion.Rating)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteFailuresToCsv writes the url of every list and film that failed to
+// scrape or parse and why.
+func WriteFailuresToCsv(failures []lb.Failure, path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{"Url", "Error"})
+	if err != nil {
+		return err
+	}
+
+	for _, failure := range failures {
+		err = writer.Write([]string{failure.URL, failure.Err.Error()})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func WriteRetriesToCsv(retries map[string]int, path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{"Url", "Retries"})
+	if err != nil {
+		return err
+	}
+
+	urls := []string{}
+	for url := range retries {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	for _, url := range urls {
+		err = writer.Write([]string{url, strconv.Itoa(retries[url])})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// multiValueSeparator separates the values of multi-valued fields in a single
+// CSV column.
+const multiValueSeparator = "; "
+
+func joinNames(contributors []lb.Contributor) string {
+	names := []string{}
+	for _, contributor := range contributors {
+		names = append(names, contributor.Name)
+	}
+
+	return strings.Join(names, multiValueSeparator)
+}
+
+// formatOptionalInt formats value, leaving the column empty if it is unknown.
+func formatOptionalInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(value)
+}
+
+// joinCast joins the cast in billing order, with each actor's character in
+// parentheses.
+func joinCast(cast []lb.CastMember) string {
+	names := []string{}
+	for _, castMember := range cast {
+		name := castMember.Name
+		if castMember.Character != "" {
+			name += " (" + castMember.Character + ")"
+		}
+		names = append(names, name)
+	}
+
+	return strings.Join(names, multiValueSeparator)
+}
+
+func joinCrew(crew []lb.CrewMember, role string) string {
+	contributors := []lb.Contributor{}
+	for _, crewMember := range crew {
+		if crewMember.Role == role {
+			contributors = append(contributors, crewMember.Contributor)
+		}
+	}
+
+	return joinNames(contributors)
+}
+
+// formatOptionalFloat formats value, leaving the column empty if it is unknown.
+func formatOptionalFloat(value float64) string {
+	if value == 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatRating formats rating, leaving the column empty if it is unrated.
+func formatRating(rating lb.Rating) string {
+	if !rating.Rated() {
+		return ""
+	}
+
+	return strconv.Itoa(int(rating))
+}
+
+// formatOptionalTime formats value, leaving the column empty if it is unknown.
+func formatOptionalTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+
+	return value.Format(time.RFC3339)
 }
@@ -2,26 +2,57 @@ package files
 
 import (
 	"bufio"
-	"log"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
 )
 
-func GetFilmListUrls(path string) (urls []string, err error) {
+// GetFilmListUrls reads the list urls in the file at path, one per line,
+// skipping blank lines. Every line is parsed and an error naming each line that
+// isn't a list url or short link is returned. Urls of later pages of a list are
+// normalized to the list's first page.
+func GetFilmListUrls(path string) (urls []lb.URL, err error) {
 
 	file, err := os.Open(path)
 	if err != nil {
-		log.Fatal("Unable to open file", err)
+		return nil, fmt.Errorf("unable to open lists file: %w", err)
 	}
 	defer func() {
-		err = file.Close()
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
 	}()
 
 	scanner := bufio.NewScanner(file)
 
-	listUrls := []string{}
+	listUrls := []lb.URL{}
+	var lineErrs []error
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		url, err := lb.ParseListURL(text)
+		if err != nil {
+			lineErrs = append(lineErrs, fmt.Errorf("%s:%d: %w", path, line, err))
+			continue
+		}
+
+		listUrls = append(listUrls, url.FirstPage())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 
-	for scanner.Scan() {
-		listUrls = append(listUrls, scanner.Text())
+	if len(lineErrs) > 0 {
+		return nil, errors.Join(lineErrs...)
 	}
 
 	return listUrls, nil
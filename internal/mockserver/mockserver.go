@@ -0,0 +1,174 @@
+// Package mockserver serves a small, offline stand-in for Letterboxd from
+// embedded fixtures, with configurable failures, for developing and testing
+// the scraper without touching the live site.
+package mockserver
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// malformedPage is served in place of pages configured to be malformed.
+const malformedPage = "malformed.html"
+
+// Failures configures how the server misbehaves. Counted failures apply to the
+// first requests for each path, so a client that retries enough eventually
+// gets the page.
+type Failures struct {
+	// TooManyRequests is the number of requests for each path that respond
+	// with a 429.
+	TooManyRequests int
+
+	// RetryAfter is sent as the Retry-After header of 429s when greater
+	// than zero.
+	RetryAfter time.Duration
+
+	// ServerErrors is the number of requests for each path, after any 429s,
+	// that respond with a 500.
+	ServerErrors int
+
+	// Latency delays every response.
+	Latency time.Duration
+
+	// Malformed lists path prefixes served a malformed page that none of
+	// the parsers accept.
+	Malformed []string
+}
+
+// Server serves the embedded fixtures at the same paths as Letterboxd. The
+// page for a path is read from the index.html file in the fixture directory
+// matching the path.
+type Server struct {
+	Failures Failures
+
+	pages fs.FS
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+// New returns a Server failing as configured by failures.
+func New(failures Failures) *Server {
+
+	pages, err := fs.Sub(fixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+
+	return &Server{
+		Failures: failures,
+		pages:    pages,
+		requests: map[string]int{},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	clean := path.Clean("/" + r.URL.Path)
+	count := s.count(clean)
+
+	if s.Failures.Latency > 0 {
+		select {
+		case <-time.After(s.Failures.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if count <= s.Failures.TooManyRequests {
+		if s.Failures.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.Failures.RetryAfter.Round(time.Second)/time.Second)))
+		}
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+
+	if count <= s.Failures.TooManyRequests+s.Failures.ServerErrors {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Join(clean, "index.html"), "/")
+	if s.malformed(clean) {
+		name = malformedPage
+	}
+
+	page, err := fs.ReadFile(s.pages, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// Requests returns the number of requests made for the page at urlPath.
+func (s *Server) Requests(urlPath string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path.Clean("/"+urlPath)]
+}
+
+// Lists returns the paths of the lists the server has fixtures for.
+func (s *Server) Lists() []string {
+
+	lists := []string{}
+
+	fs.WalkDir(s.pages, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Base(name) != "index.html" {
+			return err
+		}
+
+		segments := strings.Split(path.Dir(name), "/")
+		if len(segments) == 3 && segments[1] == "list" {
+			lists = append(lists, "/"+path.Dir(name)+"/")
+		}
+
+		return nil
+	})
+
+	sort.Strings(lists)
+
+	return lists
+}
+
+func (s *Server) count(clean string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.requests == nil {
+		s.requests = map[string]int{}
+	}
+
+	s.requests[clean]++
+
+	return s.requests[clean]
+}
+
+func (s *Server) malformed(clean string) bool {
+	for _, prefix := range s.Failures.Malformed {
+		if prefix != "" && strings.HasPrefix(clean+"/", prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,169 @@
+package mockserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+	"github.com/ellis-vester/lb-scrape/scraper"
+)
+
+func TestServer_ServesListsAndFilms(t *testing.T) {
+
+	server := httptest.NewServer(New(Failures{}))
+	defer server.Close()
+
+	fetcher := scraper.NewHTTPFetcher(scraper.DefaultTimeout)
+
+	list, err := scraper.ScrapeFilmList(fetcher, server.URL+"/somebody/list/favourite-films-of-2023/", 0)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if list.Title != "Favourite Films of 2023" || list.Owner != "somebody" || list.Pages != 2 || len(list.Entries) != 3 {
+		t.Fatalf("got %+v, want 3 entries by somebody from 2 pages", list)
+	}
+
+	urls := []string{}
+	for _, entry := range list.Entries {
+		url, err := lb.ParseURL(entry.Link)
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		urls = append(urls, url.On(server.URL))
+	}
+
+	titles := []string{}
+	for result := range scraper.ScrapeFilms(context.Background(), fetcher, urls, 2, false) {
+		if result.Err != nil {
+			t.Fatalf("got %v for %v, want %v", result.Err, result.Url, nil)
+		}
+		titles = append(titles, result.Film.Title)
+	}
+
+	want := []string{"Past Lives", "Anatomy of a Fall", "Parasite"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("got %v, want %v", titles, want)
+	}
+}
+
+func TestServer_ServesRankedListAndFilmPartials(t *testing.T) {
+
+	server := httptest.NewServer(New(Failures{}))
+	defer server.Close()
+
+	fetcher := scraper.NewHTTPFetcher(scraper.DefaultTimeout)
+
+	list, err := scraper.ScrapeFilmList(fetcher, server.URL+"/critic/list/top-films/", 0)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if !list.Ranked || list.Entries[1].Link != "/film/wild-at-heart/" || list.Entries[1].Position != 2 {
+		t.Errorf("got %+v, want a ranked list with Wild at Heart second", list)
+	}
+
+	film, err := scraper.ScrapeFilm(fetcher, server.URL+"/film/wild-at-heart/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	err = scraper.ScrapeFilmCommunity(fetcher, server.URL+"/film/wild-at-heart/", &film)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if film.Community.Histogram[9] != 14607 || film.Stats.Watches != 234567 || len(film.Cast) != 3 {
+		t.Errorf("got %+v, want the film's histogram, stats and cast", film)
+	}
+}
+
+func TestServer_FailsFirstRequestsForEachPath(t *testing.T) {
+
+	mock := New(Failures{TooManyRequests: 1, ServerErrors: 1, RetryAfter: time.Second})
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	for _, want := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusOK} {
+		response, err := http.Get(server.URL + "/film/parasite-2019/")
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != want {
+			t.Errorf("got %v, want %v", response.StatusCode, want)
+		}
+		if want == http.StatusTooManyRequests && response.Header.Get("Retry-After") != "1" {
+			t.Errorf("got Retry-After %q, want %q", response.Header.Get("Retry-After"), "1")
+		}
+	}
+
+	if got := mock.Requests("/film/parasite-2019/"); got != 3 {
+		t.Errorf("got %v requests, want %v", got, 3)
+	}
+}
+
+func TestServer_ServesMalformedPages(t *testing.T) {
+
+	server := httptest.NewServer(New(Failures{Malformed: []string{"/film/parasite-2019/"}}))
+	defer server.Close()
+
+	fetcher := scraper.NewHTTPFetcher(scraper.DefaultTimeout)
+
+	_, err := scraper.ScrapeFilm(fetcher, server.URL+"/film/parasite-2019/")
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+
+	_, err = scraper.ScrapeFilm(fetcher, server.URL+"/film/past-lives/")
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+}
+
+func TestServer_Lists(t *testing.T) {
+
+	got := New(Failures{}).Lists()
+	want := []string{"/critic/list/top-films/", "/somebody/list/favourite-films-of-2023/"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestServer_RecordedSessionReplaysOffline(t *testing.T) {
+
+	server := httptest.NewServer(New(Failures{}))
+	dir := t.TempDir()
+
+	recorder := &scraper.RecordFetcher{Fetcher: scraper.NewHTTPFetcher(scraper.DefaultTimeout), Dir: dir}
+
+	recordedList, err := scraper.ScrapeFilmList(recorder, server.URL+"/critic/list/top-films/", 0)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	recordedFilm, err := scraper.ScrapeFilm(recorder, server.URL+"/film/past-lives/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	server.Close()
+
+	replayer := scraper.ReplayFetcher{Dir: dir}
+
+	replayedList, err := scraper.ScrapeFilmList(replayer, server.URL+"/critic/list/top-films/", 0)
+	if err != nil || !reflect.DeepEqual(replayedList, recordedList) {
+		t.Errorf("got %+v, %v, want %+v", replayedList, err, recordedList)
+	}
+
+	replayedFilm, err := scraper.ScrapeFilm(replayer, server.URL+"/film/past-lives/")
+	if err != nil || !reflect.DeepEqual(replayedFilm, recordedFilm) {
+		t.Errorf("got %+v, %v, want %+v", replayedFilm, err, recordedFilm)
+	}
+}
@@ -1,9 +1,12 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -26,7 +29,7 @@ var statusStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.RoundedBorder()).
 	BorderForeground(lipgloss.Color("#725AC1")).Render
 
-func NewScrapeListsModel(listsPath string, outputDir string, pollInterval int) *ScrapeListsModel {
+func NewScrapeListsModel(fetcher scraper.Fetcher, retrier *scraper.RetryFetcher, limiter *scraper.Limiter, listsPath string, outputDir string, options scraper.AggregateOptions) *ScrapeListsModel {
 
 	listProgress := progress.New(progress.WithSolidFill("#DEEFB7"))
 	listProgress.Width = 80
@@ -37,14 +40,21 @@ func NewScrapeListsModel(listsPath string, outputDir string, pollInterval int) *
 	progressSpinner := spinner.New()
 	progressSpinner.Spinner = spinner.Pulse
 
+	if options.BaseURL == "" {
+		options.BaseURL = lb.DefaultBaseURL
+	}
+
 	return &ScrapeListsModel{
 		spinner:      progressSpinner,
 		listProgress: listProgress,
 		filmProgress: filmProgress,
 		err:          nil,
+		Fetcher:      fetcher,
+		Retrier:      retrier,
+		Limiter:      limiter,
 		ListsPath:    listsPath,
 		OutputDir:    outputDir,
-		PollInterval: pollInterval,
+		Options:      options,
 	}
 }
 
@@ -54,21 +64,26 @@ type ScrapeListsModel struct {
 	filmProgress progress.Model
 	status       string
 	err          error
-
-	UnscrapedLists []string
-	ScrapedLists   [][]lb.FilmListEntry
-
-	UnscrapedFilms []lb.FilmListEntry
-	ScrapedFilms   []lb.Film
-	Directors      []lb.Director
-
-	ListsPath    string
-	OutputDir    string
-	PollInterval int
+	cancel       context.CancelFunc
+	filmResults  <-chan scraper.FilmResult
+
+	UnscrapedLists []lb.URL
+	ScrapedPages   int
+
+	// Aggregation holds the lists and films scraped so far, aggregated as
+	// they're scraped.
+	Aggregation scraper.Aggregation
+
+	Fetcher   scraper.Fetcher
+	Retrier   *scraper.RetryFetcher
+	Limiter   *scraper.Limiter
+	ListsPath string
+	OutputDir string
+	Options   scraper.AggregateOptions
 }
 
 func (m ScrapeListsModel) Init() tea.Cmd {
-	return tea.Batch(getLists(m.ListsPath), m.spinner.Tick)
+	return tea.Batch(getLists(m.Fetcher, m.ListsPath, m.Options.BaseURL), m.spinner.Tick)
 }
 
 type startMsg string
@@ -82,82 +97,89 @@ func (m ScrapeListsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
+			m.stop()
 			return m, tea.Quit
 		}
 	case listsReadFromDiskMsg:
 		m.status = "Read lists from disk"
 		if msg.Err != nil {
-			m.status = m.err.Error()
+			m.err = msg.Err
+			m.status = msg.Err.Error()
 			return m, tea.Quit
 		}
 
 		m.UnscrapedLists = msg.Lists
 
-		if len(m.UnscrapedLists) != len(m.ScrapedLists) {
-			m.status = "Scraping list " + m.UnscrapedLists[len(m.ScrapedLists)]
+		if len(m.UnscrapedLists) != len(m.Aggregation.Lists) {
+			m.status = "Scraping list " + m.nextList()
 
-			cmd = scrapeFilmList(m.UnscrapedLists[len(m.ScrapedLists)], m.PollInterval)
+			cmd = scrapeFilmList(m.Fetcher, m.nextList(), m.Options.MaxPages)
 			cmds = append(cmds, cmd)
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
 	case listScrapedResponseMsg:
 		if msg.Err != nil {
+			m.err = msg.Err
 			m.status = msg.Err.Error()
 			return m, tea.Quit
 		}
 
-		m.ScrapedLists = append(m.ScrapedLists, msg.Films)
+		m.Aggregation.AddList(msg.URL, msg.List, msg.Err)
+		m.ScrapedPages += msg.List.Pages
 
-		if len(m.ScrapedLists) != len(m.UnscrapedLists) {
-			m.status = "Scraping list " + m.UnscrapedLists[len(m.ScrapedLists)]
+		if len(m.Aggregation.Lists) != len(m.UnscrapedLists) {
+			m.status = "Scraped " + listName(msg.List) + ", scraping list " + m.nextList()
 
-			cmd = scrapeFilmList(m.UnscrapedLists[len(m.ScrapedLists)], m.PollInterval)
+			cmd = scrapeFilmList(m.Fetcher, m.nextList(), m.Options.MaxPages)
 			cmds = append(cmds, cmd)
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
 
-		if len(m.ScrapedLists) == len(m.UnscrapedLists) {
+		if len(m.Aggregation.Lists) == len(m.UnscrapedLists) {
 			// Dedup, then start scraping films
-			m.UnscrapedFilms = scraper.SumFilmInclusions(m.ScrapedLists)
-			m.status = "Scraping film " + m.UnscrapedFilms[len(m.ScrapedFilms)].Link
+			urls := m.Aggregation.RankFilms(m.Options)
+			if len(m.Aggregation.Failures) != 0 {
+				failure := m.Aggregation.Failures[0]
+				m.err = fmt.Errorf("error ranking %s: %w", failure.URL, failure.Err)
+				m.status = m.err.Error()
+				return m, tea.Quit
+			}
+			if len(urls) == 0 {
+				return m.finish()
+			}
+
+			m.status = "Scraping films"
+
+			ctx, cancel := context.WithCancel(context.Background())
+			m.cancel = cancel
+			m.filmResults = scraper.ScrapeFilms(ctx, m.Fetcher, urls, m.Options.Workers, m.Options.NoCommunity)
 
-			cmd = scrapeFilm(m.UnscrapedFilms[len(m.ScrapedFilms)], m.PollInterval)
+			cmd = waitForFilm(m.filmResults)
 			cmds = append(cmds, cmd)
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
 	case filmScrapedResponseMsg:
 		if msg.Err != nil {
+			m.stop()
+			m.err = msg.Err
 			m.status = msg.Err.Error()
 			return m, tea.Quit
 		}
 
-		m.ScrapedFilms = append(m.ScrapedFilms, lb.Film{
-			Title:      msg.Film.Title,
-			Director:   msg.Film.Director,
-			Year:       msg.Film.Year,
-			Rating:     m.UnscrapedFilms[len(m.ScrapedFilms)].Rating,
-			Inclusions: m.UnscrapedFilms[len(m.ScrapedFilms)].Inclusions,
-			Link:       m.UnscrapedFilms[len(m.ScrapedFilms)].Link,
-		})
+		m.Aggregation.AddFilm(scraper.FilmResult(msg))
 
-		if len(m.UnscrapedFilms) != len(m.ScrapedFilms) {
-			m.status = "Scraping film " + m.UnscrapedFilms[len(m.ScrapedFilms)].Link
+		if len(m.Aggregation.Entries) != len(m.Aggregation.Films) {
+			m.status = "Scraped film " + msg.Url
 
-			cmd = scrapeFilm(m.UnscrapedFilms[len(m.ScrapedFilms)], m.PollInterval)
+			cmd = waitForFilm(m.filmResults)
 			cmds = append(cmds, cmd)
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		} else {
-
-			m.Directors = scraper.SumDirectorInclusions(m.ScrapedFilms)
-			m.status = "Writing to disk..."
-			files.WriteFilmsToCsv(m.ScrapedFilms, m.OutputDir+"/films.csv")
-			files.WriteDirectorsToCsv(m.Directors, m.OutputDir+"/directors.csv")
-			m.status = "Done!"
-			return m, tea.Quit
+			return m.finish()
 		}
 	default:
 		var cmd tea.Cmd
@@ -168,24 +190,109 @@ func (m ScrapeListsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// nextList returns the url of the next list to scrape.
+func (m ScrapeListsModel) nextList() string {
+	return m.UnscrapedLists[len(m.Aggregation.Lists)].On(m.Options.BaseURL)
+}
+
+// finish aggregates the scraped films and writes the results to disk.
+func (m ScrapeListsModel) finish() (tea.Model, tea.Cmd) {
+	m.stop()
+
+	m.Aggregation.SumFilms()
+	m.status = "Writing to disk..."
+	err := files.WriteOutputsToCsv(
+		m.OutputDir,
+		m.Aggregation.Films,
+		m.Aggregation.Directors,
+		m.Aggregation.People,
+		m.Aggregation.Lists,
+		m.Aggregation.Owners)
+	if m.Retrier != nil {
+		err = errors.Join(err, files.WriteRetriesToCsv(m.Retrier.Retries(), filepath.Join(m.OutputDir, "retries.csv")))
+	}
+	if err != nil {
+		m.err = err
+		m.status = err.Error()
+		return m, tea.Quit
+	}
+	m.status = "Done!"
+	return m, tea.Quit
+}
+
+// Err returns the error that stopped the run, if any.
+func (m ScrapeListsModel) Err() error {
+	return m.err
+}
+
+// Summary describes the outcome of the run, led by the error that stopped it
+// if there was one, followed by the films whose rating histogram or stats
+// couldn't be scraped.
+func (m ScrapeListsModel) Summary() string {
+	summary := fmt.Sprintf("Scraped %d lists (%d pages) and %d films with %d retries at %.2f requests per second.",
+		len(m.Aggregation.Lists),
+		m.ScrapedPages,
+		len(m.Aggregation.Films),
+		m.retries(),
+		m.rate())
+
+	if m.err != nil {
+		summary = "Oh no! " + m.err.Error() + "\n" + summary
+	} else {
+		summary = m.status + " " + summary
+	}
+
+	for _, warning := range m.Aggregation.Warnings {
+		summary += "\nWarning: " + warning.Err.Error()
+	}
+
+	return summary
+}
+
+func (m ScrapeListsModel) retries() int {
+	if m.Retrier == nil {
+		return 0
+	}
+
+	return m.Retrier.TotalRetries()
+}
+
+func (m ScrapeListsModel) rate() float64 {
+	if m.Limiter == nil {
+		return 0
+	}
+
+	return m.Limiter.Rate()
+}
+
+// stop cancels any films still being scraped.
+func (m ScrapeListsModel) stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
 func (m ScrapeListsModel) View() string {
 	progressPad := strings.Repeat(" ", 2)
 	detailsPad := strings.Repeat(" ", 99)
 
+	retries := m.retries()
+	rate := m.rate()
+
 	listDenominator := len(m.UnscrapedLists)
 	if listDenominator == 0 {
 
 		listDenominator = 1
 	}
 
-	filmDenominator := len(m.UnscrapedFilms)
+	filmDenominator := len(m.Aggregation.Entries)
 	if filmDenominator == 0 {
 		filmDenominator = 1
 	}
 
 	scrapedFilm := lb.Film{
 		Title:      "",
-		Director:   "",
+		Directors:  nil,
 		Year:       0,
 		Rating:     0,
 		Inclusions: 0,
@@ -205,103 +312,111 @@ func (m ScrapeListsModel) View() string {
                                       \|_________|
   `
 
-	if len(m.ScrapedFilms) != 0 {
-		scrapedFilm = m.ScrapedFilms[len(m.ScrapedFilms)-1]
+	if len(m.Aggregation.Films) != 0 {
+		scrapedFilm = m.Aggregation.Films[len(m.Aggregation.Films)-1]
 		filmDisplay = statusStyle("\n" + titleStyle("  Title:      ") + textStyle(scrapedFilm.Title) + "\n" +
-			titleStyle("  Director:   ") + textStyle(scrapedFilm.Director) + "\n" +
+			titleStyle("  Director:   ") + textStyle(directorNames(scrapedFilm.Directors)) + "\n" +
 			titleStyle("  Year:       ") + textStyle(strconv.FormatInt(int64(scrapedFilm.Year), 10)) + "\n" +
 			titleStyle("  Inclusions: ") + textStyle(strconv.FormatInt(int64(scrapedFilm.Inclusions), 10)) + "\n" +
-			titleStyle("  Link:       ") + textStyle("https://letterboxd.com"+scrapedFilm.Link) + "\n" + detailsPad)
+			titleStyle("  Link:       ") + textStyle(m.filmURL(scrapedFilm.Link)) + "\n" + detailsPad)
 	}
 
 	return headerStyle(title) + "\n" +
 		statusStyle("\n"+progressPad+m.spinner.View()+" "+m.status+"\n\n"+
-			progressPad+titleStyle("Lists: ")+m.listProgress.ViewAs(float64(len(m.ScrapedLists))/float64(listDenominator))+progressPad+"        "+"\n\n"+
-			progressPad+titleStyle("Films: ")+m.filmProgress.ViewAs(float64(len(m.ScrapedFilms))/float64(filmDenominator))+progressPad+"        "+"\n\n") +
+			progressPad+titleStyle("Lists: ")+m.listProgress.ViewAs(float64(len(m.Aggregation.Lists))/float64(listDenominator))+progressPad+"        "+"\n"+
+			progressPad+titleStyle("Pages: ")+textStyle(strconv.Itoa(m.ScrapedPages))+
+			progressPad+titleStyle("Retries: ")+textStyle(strconv.Itoa(retries))+
+			progressPad+titleStyle("Rate: ")+textStyle(strconv.FormatFloat(rate, 'f', 2, 64)+" req/s")+"\n\n"+
+			progressPad+titleStyle("Films: ")+m.filmProgress.ViewAs(float64(len(m.Aggregation.Films))/float64(filmDenominator))+progressPad+"        "+"\n\n") +
 		"\n" + filmDisplay + "\n" +
 		progressPad + helpStyle("Press q or ctrl+c to quit") + "\n"
 }
 
+// filmURL returns the url of the film at link on the site being scraped.
+func (m ScrapeListsModel) filmURL(link string) string {
+	url, err := lb.ParseURL(link)
+	if err != nil {
+		return link
+	}
+
+	return url.On(m.Options.BaseURL)
+}
+
+// listName names a list by its title and owner, falling back to its url.
+func listName(list lb.List) string {
+	if list.Title == "" {
+		return list.URL
+	}
+
+	if list.OwnerName == "" {
+		return strconv.Quote(list.Title)
+	}
+
+	return strconv.Quote(list.Title) + " by " + list.OwnerName
+}
+
+func directorNames(directors []lb.Contributor) string {
+	names := []string{}
+	for _, director := range directors {
+		names = append(names, director.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
 // Messages
 type listsReadFromDiskMsg struct {
-	Lists []string
+	Lists []lb.URL
 	Err   error
 }
 
 type listScrapedResponseMsg struct {
-	Films []lb.FilmListEntry
-	Err   error
-}
-
-type filmScrapedResponseMsg struct {
-	Film lb.Film
+	URL  string
+	List lb.List
 	Err  error
 }
 
+type filmScrapedResponseMsg scraper.FilmResult
+
 type filmScrapedMsg lb.Film
 
 // Commands
-func getLists(path string) tea.Cmd {
+// getLists reads the list urls at path and resolves any short links among
+// them, so that bad input is reported before any list is scraped.
+func getLists(fetcher scraper.Fetcher, path string, baseURL string) tea.Cmd {
 	return func() tea.Msg {
 		urls, err := files.GetFilmListUrls(path)
-		return listsReadFromDiskMsg{
-			Lists: urls,
-			Err:   err,
-		}
-	}
-}
-
-func scrapeFilmList(url string, interval int) tea.Cmd {
-	return func() tea.Msg {
-		time.Sleep(time.Duration(interval) * time.Second)
-
-		html, err := scraper.ScrapeFilmListHtml(url)
 		if err != nil {
-			return listScrapedResponseMsg{
-				Films: nil,
-				Err:   err,
-			}
+			return listsReadFromDiskMsg{Err: err}
 		}
 
-		films, err := scraper.ParseFilmList(html)
+		lists, err := scraper.ResolveLists(fetcher, urls, baseURL)
 		if err != nil {
-			return listScrapedResponseMsg{
-				Films: nil,
-				Err:   err,
-			}
+			return listsReadFromDiskMsg{Err: err}
 		}
 
-		return listScrapedResponseMsg{
-			Films: films,
-			Err:   nil,
-		}
+		return listsReadFromDiskMsg{Lists: lists}
 	}
 }
 
-func scrapeFilm(film lb.FilmListEntry, interval int) tea.Cmd {
+func scrapeFilmList(fetcher scraper.Fetcher, url string, maxPages int) tea.Cmd {
 	return func() tea.Msg {
-
-		time.Sleep(time.Duration(interval) * time.Second)
-
-		html, err := scraper.ScrapeFilmHtml("https://letterboxd.com" + film.Link)
-		if err != nil {
-			return filmScrapedResponseMsg{
-				Film: lb.Film{},
-				Err:  err,
-			}
+		list, err := scraper.ScrapeFilmList(fetcher, url, maxPages)
+		return listScrapedResponseMsg{
+			URL:  url,
+			List: list,
+			Err:  err,
 		}
+	}
+}
 
-		film, err := scraper.ParseFilm(html)
-		if err != nil {
-			return filmScrapedResponseMsg{
-				Film: lb.Film{},
-				Err:  err,
-			}
+func waitForFilm(results <-chan scraper.FilmResult) tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-results
+		if !ok {
+			return nil
 		}
 
-		return filmScrapedResponseMsg{
-			Film: film,
-			Err:  nil,
-		}
+		return filmScrapedResponseMsg(result)
 	}
 }
@@ -0,0 +1,15 @@
+package letterboxd
+
+// CommunityRating summarises how Letterboxd members have rated a film.
+type CommunityRating struct {
+	Average   float64 // Letterboxd's weighted average out of 5, or 0 if too few members have rated the film.
+	Count     int
+	Histogram [10]int // The number of ratings of each half star, from half a star to five stars.
+}
+
+// FilmStats counts how Letterboxd members have interacted with a film.
+type FilmStats struct {
+	Watches int
+	Lists   int
+	Likes   int
+}
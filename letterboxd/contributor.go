@@ -0,0 +1,21 @@
+package letterboxd
+
+// Contributor is a person credited on a film, identified by their Letterboxd slug.
+type Contributor struct {
+	Name string
+	Slug string
+}
+
+// CastMember is an actor credited on a film.
+type CastMember struct {
+	Contributor
+	Character string
+	Billing   int // The actor's position in the cast list, starting at 1.
+}
+
+// CrewMember is a person credited on a film in a crew role. Role is the role
+// as it appears in Letterboxd links, such as "writer" or "cinematography".
+type CrewMember struct {
+	Contributor
+	Role string
+}
@@ -5,5 +5,6 @@ package letterboxd
 // times they appear in a list or lists on Letterboxd.
 type Director struct {
 	Name       string
+	Slug       string
 	Inclusions int
 }
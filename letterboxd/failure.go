@@ -0,0 +1,7 @@
+package letterboxd
+
+// Failure records a page that couldn't be scraped or parsed, and why.
+type Failure struct {
+	URL string
+	Err error
+}
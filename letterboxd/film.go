@@ -3,10 +3,38 @@ package letterboxd
 // Film contains the data about a single film on Letterboxd.
 type Film struct {
 	Link       string
-	Rating     int8
-	UserName   string
+	Rating     Rating
+	UserName   string // The owner of the first list including the film.
 	Inclusions int
-	Director   string
+	Directors  []Contributor
 	Year       int
 	Title      string
+
+	// BestPosition is the highest rank the film reached in a ranked list, or
+	// 0 if it was only included in unranked lists.
+	BestPosition int
+	Score        float64
+	OwnerRatings RatingStats
+	Lists        []Inclusion
+
+	OriginalTitle   string
+	Runtime         int // In minutes, or 0 if unknown.
+	Genres          []string
+	Themes          []string
+	Countries       []string
+	PrimaryLanguage string
+	SpokenLanguages []string
+	Studios         []string
+
+	Cast []CastMember
+	Crew []CrewMember
+
+	Community CommunityRating
+	Stats     FilmStats
+
+	LetterboxdID int
+	Slug         string
+	TmdbID       int
+	TmdbType     string // "movie" or "tv".
+	ImdbID       string
 }
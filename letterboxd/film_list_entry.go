@@ -2,8 +2,18 @@ package letterboxd
 
 // FilmListEntry represents the data for a film in Letterboxd list.
 type FilmListEntry struct {
-	Rating     int8
+	Rating     Rating
 	Link       string
-	UserName   string
+	UserName   string // The list owner's username, or once aggregated the owner of the first list including the film.
 	Inclusions int
+	Position   int     // The entry's position in its list, starting at 1.
+	Score      float64 // The film's score across lists once aggregated.
+
+	// OwnerRatings summarises the ratings of every list including the film
+	// once aggregated.
+	OwnerRatings RatingStats
+
+	// Lists records every list including the film once aggregated, in the
+	// order the lists were scraped.
+	Lists []Inclusion
 }
@@ -0,0 +1,12 @@
+package letterboxd
+
+// Inclusion records a list that included a film, and where and how the list
+// included it.
+type Inclusion struct {
+	ListURL   string
+	ListTitle string
+	Owner     string // The username of the list's owner.
+	Position  int
+	Ranked    bool
+	Rating    Rating
+}
@@ -0,0 +1,22 @@
+package letterboxd
+
+import "time"
+
+// List is a Letterboxd list and the films in it.
+type List struct {
+	URL     string
+	Ranked  bool // Whether the list is numbered, making each entry's Position a rank.
+	Pages   int
+	Entries []FilmListEntry
+
+	Title       string
+	Owner       string // The owner's username.
+	OwnerName   string // The owner's display name.
+	Description string
+	Tags        []string
+	Published   time.Time
+	Updated     time.Time // The zero time if the list hasn't been updated since it was published.
+	Likes       int
+	Comments    int
+	EntryCount  int // The number of films Letterboxd says the list has, which may be more than were scraped.
+}
@@ -0,0 +1,15 @@
+package letterboxd
+
+// Owner represents a member whose lists were scraped, what they contributed
+// and how closely their choices match the consensus across every list.
+type Owner struct {
+	Name        string
+	DisplayName string
+	Lists       int
+	Films       int
+	Ratings     RatingStats
+
+	// ConsensusOverlap is the number of the owner's films that are among
+	// the top scoring films across every list.
+	ConsensusOverlap int
+}
@@ -0,0 +1,10 @@
+package letterboxd
+
+// Person represents a person credited in a role and the number of films
+// they are credited on in that role across a list or lists on Letterboxd.
+type Person struct {
+	Name       string
+	Slug       string
+	Role       string
+	Inclusions int
+}
@@ -0,0 +1,24 @@
+package letterboxd
+
+// Rating is a list owner's rating of a film out of 10, where each point is
+// half a star. Letterboxd ratings start at half a star, so the zero value is
+// free to mark an entry the owner hasn't rated.
+type Rating int8
+
+// Unrated is the Rating of an entry whose owner hasn't rated the film.
+const Unrated Rating = 0
+
+// Rated reports whether the owner rated the film.
+func (r Rating) Rated() bool {
+	return r != Unrated
+}
+
+// RatingStats summarises the ratings list owners gave a film. Unrated
+// entries are left out, so Count may be lower than the film's inclusions.
+type RatingStats struct {
+	Count  int
+	Mean   float64
+	Median float64
+	Min    Rating
+	Max    Rating
+}
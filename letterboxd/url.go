@@ -0,0 +1,245 @@
+package letterboxd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultBaseURL is the scheme and host of the live site, which URLs are
+// normalized to.
+const DefaultBaseURL = "https://letterboxd.com"
+
+// URLKind is the kind of page a URL refers to.
+type URLKind int
+
+const (
+	ListURL URLKind = iota + 1
+	FilmURL
+	UserURL
+	DirectorURL
+	// ShortURL is a boxd.it short link, which has to be resolved by
+	// following its redirect before its kind is known.
+	ShortURL
+)
+
+func (k URLKind) String() string {
+	switch k {
+	case ListURL:
+		return "list"
+	case FilmURL:
+		return "film"
+	case UserURL:
+		return "user"
+	case DirectorURL:
+		return "director"
+	case ShortURL:
+		return "short link"
+	default:
+		return "unknown"
+	}
+}
+
+// URL is a parsed and normalized reference to a page on Letterboxd.
+type URL struct {
+	Kind URLKind
+
+	// Username is the member a list or user URL belongs to.
+	Username string
+
+	// Slug identifies the list, film or director, or is the code of a short
+	// link.
+	Slug string
+
+	// Page is the page of a list the URL refers to, or 0 for its first page.
+	Page int
+}
+
+// sitePaths are the first path segments of Letterboxd URLs that don't belong
+// to a member.
+var sitePaths = map[string]bool{
+	"film":     true,
+	"films":    true,
+	"list":     true,
+	"lists":    true,
+	"members":  true,
+	"director": true,
+	"actor":    true,
+	"search":   true,
+	"csi":      true,
+}
+
+// ParseURL parses a Letterboxd list, film, user or director URL, or a boxd.it
+// short link. The scheme, the www. prefix and the trailing slash are optional,
+// queries and fragments are ignored, and a path on its own such as
+// /film/parasite-2019/ is taken to be on letterboxd.com.
+func ParseURL(raw string) (URL, error) {
+
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return URL{}, fmt.Errorf("empty Letterboxd url")
+	}
+
+	switch {
+	case strings.HasPrefix(trimmed, "/"):
+		trimmed = DefaultBaseURL + trimmed
+	case !strings.Contains(trimmed, "://"):
+		trimmed = "https://" + trimmed
+	}
+
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return URL{}, fmt.Errorf("invalid Letterboxd url %q: %w", raw, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return URL{}, fmt.Errorf("invalid Letterboxd url %q: unsupported scheme %q", raw, u.Scheme)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	switch strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") {
+	case "letterboxd.com":
+		parsed, ok := parsePath(segments)
+		if !ok {
+			return URL{}, fmt.Errorf("invalid Letterboxd url %q: not a list, film, user or director page", raw)
+		}
+		return parsed, nil
+	case "boxd.it":
+		if len(segments) != 1 {
+			return URL{}, fmt.Errorf("invalid Letterboxd url %q: not a short link", raw)
+		}
+		return URL{Kind: ShortURL, Slug: segments[0]}, nil
+	default:
+		return URL{}, fmt.Errorf("invalid Letterboxd url %q: %q is not letterboxd.com or boxd.it", raw, u.Host)
+	}
+}
+
+// parsePath parses the path segments of a letterboxd.com URL.
+func parsePath(segments []string) (URL, bool) {
+
+	if len(segments) == 0 {
+		return URL{}, false
+	}
+
+	switch segments[0] {
+	case "film":
+		if len(segments) != 2 {
+			return URL{}, false
+		}
+		return URL{Kind: FilmURL, Slug: segments[1]}, true
+	case "director":
+		if len(segments) != 2 {
+			return URL{}, false
+		}
+		return URL{Kind: DirectorURL, Slug: segments[1]}, true
+	}
+
+	if sitePaths[segments[0]] {
+		return URL{}, false
+	}
+
+	switch {
+	case len(segments) == 1:
+		return URL{Kind: UserURL, Username: segments[0]}, true
+	case len(segments) == 3 && segments[1] == "list":
+		return URL{Kind: ListURL, Username: segments[0], Slug: segments[2]}, true
+	case len(segments) == 5 && segments[1] == "list" && segments[3] == "page":
+		page, err := strconv.Atoi(segments[4])
+		if err != nil || page < 1 {
+			return URL{}, false
+		}
+		if page == 1 {
+			page = 0
+		}
+		return URL{Kind: ListURL, Username: segments[0], Slug: segments[2], Page: page}, true
+	default:
+		return URL{}, false
+	}
+}
+
+// ParseListURL parses a URL with ParseURL, returning an error unless it is a
+// list or a short link that may resolve to one.
+func ParseListURL(raw string) (URL, error) {
+
+	u, err := ParseURL(raw)
+	if err != nil {
+		return u, err
+	}
+
+	if u.Kind != ListURL && u.Kind != ShortURL {
+		return u, fmt.Errorf("invalid Letterboxd list url %q: it is a %s url", raw, u.Kind)
+	}
+
+	return u, nil
+}
+
+// Path returns the path of the page u refers to, with a trailing slash.
+func (u URL) Path() string {
+	switch u.Kind {
+	case ListURL:
+		path := "/" + u.Username + "/list/" + u.Slug + "/"
+		if u.Page > 1 {
+			path += "page/" + strconv.Itoa(u.Page) + "/"
+		}
+		return path
+	case FilmURL:
+		return "/film/" + u.Slug + "/"
+	case UserURL:
+		return "/" + u.Username + "/"
+	case DirectorURL:
+		return "/director/" + u.Slug + "/"
+	case ShortURL:
+		return "/" + u.Slug
+	default:
+		return ""
+	}
+}
+
+// FirstPage returns u without its page, so that it refers to the first page of
+// a list.
+func (u URL) FirstPage() URL {
+	u.Page = 0
+	return u
+}
+
+// String returns the normalized URL on the live site.
+func (u URL) String() string {
+	return u.On(DefaultBaseURL)
+}
+
+// On returns the URL of u on the site at base, such as a local mirror of
+// Letterboxd. Short links are always on boxd.it.
+func (u URL) On(base string) string {
+	if u.Kind == ShortURL {
+		return "https://boxd.it" + u.Path()
+	}
+
+	return strings.TrimSuffix(base, "/") + u.Path()
+}
+
+// ParseBaseURL parses the scheme and host of a site serving Letterboxd's pages
+// at the same paths as the live site, returning it without a trailing slash.
+func ParseBaseURL(raw string) (string, error) {
+
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid base url %q: %w", raw, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid base url %q: expected an http or https url such as %s", raw, DefaultBaseURL)
+	}
+
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid base url %q: unexpected path, query or fragment", raw)
+	}
+
+	return u.Scheme + "://" + u.Host, nil
+}
@@ -0,0 +1,108 @@
+package letterboxd
+
+import (
+	"testing"
+)
+
+func TestParseURL_NormalizesUrls(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want URL
+		str  string
+	}{
+		{"https://letterboxd.com/user/list/favs/", URL{Kind: ListURL, Username: "user", Slug: "favs"}, "https://letterboxd.com/user/list/favs/"},
+		{"letterboxd.com/user/list/favs", URL{Kind: ListURL, Username: "user", Slug: "favs"}, "https://letterboxd.com/user/list/favs/"},
+		{" http://www.letterboxd.com/user/list/favs/?by=rating#top ", URL{Kind: ListURL, Username: "user", Slug: "favs"}, "https://letterboxd.com/user/list/favs/"},
+		{"https://letterboxd.com/user/list/favs/page/3/", URL{Kind: ListURL, Username: "user", Slug: "favs", Page: 3}, "https://letterboxd.com/user/list/favs/page/3/"},
+		{"https://letterboxd.com/user/list/favs/page/1/", URL{Kind: ListURL, Username: "user", Slug: "favs"}, "https://letterboxd.com/user/list/favs/"},
+		{"/film/parasite-2019/", URL{Kind: FilmURL, Slug: "parasite-2019"}, "https://letterboxd.com/film/parasite-2019/"},
+		{"https://letterboxd.com/director/bong-joon-ho", URL{Kind: DirectorURL, Slug: "bong-joon-ho"}, "https://letterboxd.com/director/bong-joon-ho/"},
+		{"https://letterboxd.com/user/", URL{Kind: UserURL, Username: "user"}, "https://letterboxd.com/user/"},
+		{"boxd.it/abc1", URL{Kind: ShortURL, Slug: "abc1"}, "https://boxd.it/abc1"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseURL(test.raw)
+		if err != nil {
+			t.Errorf("got %v for %q, want %v", err, test.raw, nil)
+			continue
+		}
+
+		if got != test.want || got.String() != test.str {
+			t.Errorf("got %+v (%v) for %q, want %+v (%v)", got, got, test.raw, test.want, test.str)
+		}
+	}
+}
+
+func TestParseURL_ReturnsErrorForBadUrls(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"   ",
+		"https://example.com/user/list/favs/",
+		"ftp://letterboxd.com/user/list/favs/",
+		"https://letterboxd.com/",
+		"https://letterboxd.com/film/",
+		"https://letterboxd.com/film/parasite-2019/reviews/",
+		"https://letterboxd.com/films/popular/",
+		"https://letterboxd.com/user/list/favs/page/zero/",
+		"https://letterboxd.com/user/list/favs/page/0/",
+		"https://boxd.it/",
+	} {
+		got, err := ParseURL(raw)
+		if err == nil {
+			t.Errorf("got %+v for %q, want error", got, raw)
+		}
+	}
+}
+
+func TestParseListURL_ReturnsErrorForOtherKinds(t *testing.T) {
+	for _, raw := range []string{
+		"https://letterboxd.com/film/parasite-2019/",
+		"https://letterboxd.com/user/",
+	} {
+		_, err := ParseListURL(raw)
+		if err == nil {
+			t.Errorf("got nil for %q, want error", raw)
+		}
+	}
+
+	for _, raw := range []string{
+		"https://letterboxd.com/user/list/favs/",
+		"https://boxd.it/abc1",
+	} {
+		_, err := ParseListURL(raw)
+		if err != nil {
+			t.Errorf("got %v for %q, want nil", err, raw)
+		}
+	}
+}
+
+func TestURL_On(t *testing.T) {
+	u := URL{Kind: ListURL, Username: "user", Slug: "favs", Page: 2}
+
+	got := u.On("http://127.0.0.1:8080/")
+	want := "http://127.0.0.1:8080/user/list/favs/page/2/"
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseBaseURL(t *testing.T) {
+	for raw, want := range map[string]string{
+		"https://letterboxd.com":  "https://letterboxd.com",
+		"http://127.0.0.1:8080/":  "http://127.0.0.1:8080",
+		" http://localhost:3000 ": "http://localhost:3000",
+	} {
+		got, err := ParseBaseURL(raw)
+		if err != nil || got != want {
+			t.Errorf("got %q, %v for %q, want %q", got, err, raw, want)
+		}
+	}
+
+	for _, raw := range []string{"", "letterboxd.com", "ftp://letterboxd.com", "http://localhost/mirror/", "http://localhost/?a=b"} {
+		got, err := ParseBaseURL(raw)
+		if err == nil {
+			t.Errorf("got %q for %q, want error", got, raw)
+		}
+	}
+}
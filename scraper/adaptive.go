@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+)
+
+// AdaptiveFetcher wraps a Fetcher, adjusting the rate of Limiter as Letterboxd
+// responds. Each fast, successful response adds Increase to the rate, while a
+// 429, a 5xx or a response slower than SlowLatency multiplies the rate by
+// Decrease. The rate is kept between MinRate and MaxRate, and never set to
+// zero or less.
+//
+// The Limiter should be waited on before requests reach the AdaptiveFetcher so
+// that time spent waiting isn't counted as latency.
+type AdaptiveFetcher struct {
+	Fetcher     Fetcher
+	Limiter     *Limiter
+	MinRate     float64
+	MaxRate     float64
+	Increase    float64
+	Decrease    float64
+	SlowLatency time.Duration
+
+	now func() time.Time
+}
+
+// NewAdaptiveFetcher returns an AdaptiveFetcher keeping the rate of limiter
+// between minRate and maxRate with the default increase, decrease and latency.
+func NewAdaptiveFetcher(fetcher Fetcher, limiter *Limiter, minRate float64, maxRate float64) *AdaptiveFetcher {
+	return &AdaptiveFetcher{
+		Fetcher:     fetcher,
+		Limiter:     limiter,
+		MinRate:     minRate,
+		MaxRate:     maxRate,
+		Increase:    0.05,
+		Decrease:    0.5,
+		SlowLatency: 3 * time.Second,
+	}
+}
+
+func (f *AdaptiveFetcher) Fetch(url string) (*Response, error) {
+
+	start := f.clock()
+	response, err := f.Fetcher.Fetch(url)
+	latency := f.clock().Sub(start)
+
+	rate := f.Limiter.Rate()
+
+	if throttled(err) || (f.SlowLatency > 0 && latency > f.SlowLatency) {
+		rate *= f.Decrease
+	} else if err == nil {
+		rate += f.Increase
+	}
+
+	// A rate of zero would lift the limit entirely, so the rate is only
+	// changed while it stays above zero.
+	rate = math.Min(math.Max(rate, f.MinRate), f.MaxRate)
+	if rate > 0 {
+		f.Limiter.SetRate(rate)
+	}
+
+	return response, err
+}
+
+func (f *AdaptiveFetcher) clock() time.Time {
+	if f.now == nil {
+		return time.Now()
+	}
+
+	return f.now()
+}
+
+// throttled reports whether a request failed because Letterboxd is
+// overloaded or asking us to slow down.
+func throttled(err error) bool {
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+
+	return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+}
@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveFetcher_IncreasesRateOnSuccess(t *testing.T) {
+
+	limiter := NewLimiter(1, 1)
+	fetcher := NewAdaptiveFetcher(&scriptedFetcher{Statuses: []int{200}}, limiter, 0.5, 1.1)
+
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if got := limiter.Rate(); got != 1.05 {
+		t.Errorf("got %v, want %v", got, 1.05)
+	}
+
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if got := limiter.Rate(); got != 1.1 {
+		t.Errorf("got %v, want %v", got, 1.1)
+	}
+}
+
+func TestAdaptiveFetcher_DecreasesRateWhenThrottled(t *testing.T) {
+
+	limiter := NewLimiter(1, 1)
+	fetcher := NewAdaptiveFetcher(&scriptedFetcher{Statuses: []int{429, 503, 500}}, limiter, 0.2, 2)
+
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if got := limiter.Rate(); got != 0.5 {
+		t.Errorf("got %v, want %v", got, 0.5)
+	}
+
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if got := limiter.Rate(); got != 0.2 {
+		t.Errorf("got %v, want %v", got, 0.2)
+	}
+}
+
+func TestAdaptiveFetcher_DecreasesRateWhenSlow(t *testing.T) {
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter := NewLimiter(1, 1)
+	fetcher := NewAdaptiveFetcher(&scriptedFetcher{Statuses: []int{200}}, limiter, 0.1, 2)
+	fetcher.now = func() time.Time {
+		now = now.Add(2 * time.Second)
+		return now
+	}
+	fetcher.SlowLatency = time.Second
+
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if got := limiter.Rate(); got != 0.5 {
+		t.Errorf("got %v, want %v", got, 0.5)
+	}
+}
+
+func TestAdaptiveFetcher_LeavesRateOnClientErrors(t *testing.T) {
+
+	limiter := NewLimiter(1, 1)
+	fetcher := NewAdaptiveFetcher(&scriptedFetcher{Statuses: []int{404}}, limiter, 0.1, 2)
+
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if got := limiter.Rate(); got != 1 {
+		t.Errorf("got %v, want %v", got, 1)
+	}
+}
+
+func TestAdaptiveFetcher_NeverLiftsTheLimit(t *testing.T) {
+
+	limiter := NewLimiter(1, 1)
+	fetcher := NewAdaptiveFetcher(&scriptedFetcher{Statuses: []int{429, 429, 429}}, limiter, 0, 2)
+
+	for i := 0; i < 3; i++ {
+		fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	}
+
+	if got := limiter.Rate(); got != 0.125 {
+		t.Errorf("got %v, want %v", got, 0.125)
+	}
+
+	limiter.SetRate(0.0001)
+	fetcher.Decrease = 0
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+
+	if got := limiter.Rate(); got != 0.0001 {
+		t.Errorf("got %v, want %v", got, 0.0001)
+	}
+}
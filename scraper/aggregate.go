@@ -0,0 +1,140 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+// AggregateOptions configures Aggregate as the scrape-lists flags of the same
+// names do.
+type AggregateOptions struct {
+	MaxPages int
+	Workers  int
+	Scoring  Scoring
+	TopN     int
+
+	// ConsensusSize is the number of top scoring films owners are compared
+	// with.
+	ConsensusSize int
+
+	// NoCommunity skips scraping each film's rating histogram and stats.
+	NoCommunity bool
+
+	// BaseURL is the site the lists' films are fetched from, defaulting to
+	// the live site.
+	BaseURL string
+}
+
+// Aggregation is the result of scraping and aggregating a set of lists. It's
+// built up a step at a time with AddList, RankFilms, AddFilm and SumFilms, so
+// that the TUI can report progress between them.
+type Aggregation struct {
+	Lists     []lb.List
+	Films     []lb.Film
+	Directors []lb.Director
+	People    []lb.Person
+	Owners    []lb.Owner
+	Failures  []lb.Failure
+
+	// Entries are the films of Lists merged and scored by RankFilms, in the
+	// order they're scraped in.
+	Entries []lb.FilmListEntry
+
+	// Warnings are the films whose rating histogram or stats couldn't be
+	// scraped. The films are still aggregated without them.
+	Warnings []lb.Failure
+}
+
+// Aggregate scrapes the lists at urls and every film in them, and aggregates
+// them as scrape-lists does. Unlike scrape-lists it carries on past lists and
+// films that fail, returning them as Failures, so that it can report every page
+// stored pages no longer parse from.
+func Aggregate(ctx context.Context, fetcher Fetcher, urls []string, options AggregateOptions) Aggregation {
+
+	aggregation := Aggregation{}
+
+	for _, url := range urls {
+		list, err := ScrapeFilmList(fetcher, url, options.MaxPages)
+		aggregation.AddList(url, list, err)
+	}
+
+	filmURLs := aggregation.RankFilms(options)
+
+	for result := range ScrapeFilms(ctx, fetcher, filmURLs, options.Workers, options.NoCommunity) {
+		aggregation.AddFilm(result)
+	}
+
+	aggregation.SumFilms()
+
+	return aggregation
+}
+
+// AddList adds the list scraped from url, or records it as a failure if err
+// isn't nil.
+func (a *Aggregation) AddList(url string, list lb.List, err error) {
+	if err != nil {
+		a.Failures = append(a.Failures, lb.Failure{URL: url, Err: err})
+		return
+	}
+
+	a.Lists = append(a.Lists, list)
+}
+
+// RankFilms merges and scores the films of every list added, summarises their
+// owners and returns the urls to scrape the films from on options.BaseURL, in
+// the order of Entries. Entries whose link isn't a film url are recorded as
+// failures and left out of Entries.
+func (a *Aggregation) RankFilms(options AggregateOptions) []string {
+
+	baseURL := options.BaseURL
+	if baseURL == "" {
+		baseURL = lb.DefaultBaseURL
+	}
+
+	entries := SumFilmInclusions(a.Lists)
+	entries = ScoreFilms(entries, a.Lists, options.Scoring, options.TopN)
+
+	a.Entries = []lb.FilmListEntry{}
+	urls := []string{}
+	for _, entry := range entries {
+		url, err := lb.ParseURL(entry.Link)
+		if err == nil && url.Kind != lb.FilmURL {
+			err = fmt.Errorf("%s is a %s url, not a film", entry.Link, url.Kind)
+		}
+		if err != nil {
+			a.Failures = append(a.Failures, lb.Failure{URL: entry.Link, Err: err})
+			continue
+		}
+
+		a.Entries = append(a.Entries, entry)
+		urls = append(urls, url.On(baseURL))
+	}
+
+	a.Owners = SumOwners(a.Lists, a.Entries, options.ConsensusSize)
+
+	return urls
+}
+
+// AddFilm merges a film scraped from the urls RankFilms returned with its
+// entry, or records it as a failure. A film whose rating histogram or stats
+// failed is still added, and recorded as a warning.
+func (a *Aggregation) AddFilm(result FilmResult) {
+	if result.Err != nil {
+		a.Failures = append(a.Failures, lb.Failure{URL: result.Url, Err: result.Err})
+		return
+	}
+
+	if result.CommunityErr != nil {
+		a.Warnings = append(a.Warnings, lb.Failure{URL: result.Url, Err: result.CommunityErr})
+	}
+
+	a.Films = append(a.Films, MergeListEntry(result.Film, a.Entries[result.Index]))
+}
+
+// SumFilms aggregates the directors and people of the films added.
+func (a *Aggregation) SumFilms() {
+	a.Directors = SumDirectorInclusions(a.Films)
+	a.People = SumPeopleInclusions(a.Films)
+}
@@ -0,0 +1,97 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+func TestAggregate_ReportsFailuresAndCarriesOn(t *testing.T) {
+
+	fetcher := MapFetcher{
+		"https://letterboxd.com/user/list/good/": `<ul class="poster-list">
+			<li class="poster-container" data-owner-rating="10"><div class="film-poster" data-target-link="/film/film-1/"></div></li>
+			<li class="poster-container" data-owner-rating="8"><div class="film-poster" data-target-link="/film/film-2/"></div></li>
+		</ul>`,
+		"https://letterboxd.com/user/list/bad/": `<ul class="poster-list">
+			<li class="poster-container" data-owner-rating="eleven"><div class="film-poster" data-target-link="/film/film-1/"></div></li>
+		</ul>`,
+		"https://letterboxd.com/film/film-1/": filmPage("Film 1"),
+		"https://letterboxd.com/film/film-2/": `<html><body></body></html>`,
+	}
+
+	got := Aggregate(context.Background(), fetcher, []string{
+		"https://letterboxd.com/user/list/good/",
+		"https://letterboxd.com/user/list/bad/",
+	}, AggregateOptions{Workers: 2, Scoring: ScoreInclusions})
+
+	if len(got.Lists) != 1 || len(got.Films) != 1 || got.Films[0].Title != "Film 1" || got.Films[0].Inclusions != 1 {
+		t.Errorf("got %+v, want Film 1 from the good list", got)
+	}
+
+	if len(got.Directors) != 1 || len(got.Owners) != 1 {
+		t.Errorf("got %+v and %+v, want Film 1's director and the good list's owner", got.Directors, got.Owners)
+	}
+
+	want := []string{"https://letterboxd.com/user/list/bad/", "https://letterboxd.com/film/film-2/"}
+	if len(got.Failures) != len(want) {
+		t.Fatalf("got %+v, want failures for %v", got.Failures, want)
+	}
+	for i, failure := range got.Failures {
+		if failure.URL != want[i] || failure.Err == nil {
+			t.Errorf("got %+v, want a failure for %v", failure, want[i])
+		}
+	}
+}
+
+func TestAggregate_ReparsesStoredPages(t *testing.T) {
+
+	dir := t.TempDir()
+	recorder := &RecordFetcher{Fetcher: MapFetcher{
+		"https://letterboxd.com/user/list/good/": `<ul class="poster-list">
+			<li class="poster-container" data-owner-rating="10"><div class="film-poster" data-target-link="/film/film-1/"></div></li>
+		</ul>`,
+		"https://letterboxd.com/film/film-1/": filmPage("Film 1"),
+	}, Dir: dir}
+
+	// Store the pages without their fragments, as a cache would.
+	recorder.Fetch("https://letterboxd.com/user/list/good/")
+	recorder.Fetch("https://letterboxd.com/film/film-1/")
+
+	got := Aggregate(context.Background(), ReplayFetcher{Dir: dir}, []string{"https://letterboxd.com/user/list/good/"}, AggregateOptions{})
+
+	if len(got.Failures) != 0 || len(got.Films) != 1 || got.Films[0].Title != "Film 1" {
+		t.Errorf("got %+v, want Film 1 without failures", got)
+	}
+
+	_, err := ReplayFetcher{Dir: dir}.Fetch("https://letterboxd.com/csi/film/film-1/stats/")
+	if !errors.Is(err, ErrNotStored) {
+		t.Errorf("got %v, want %v", err, ErrNotStored)
+	}
+}
+
+func TestAggregation_RankFilmsRecordsLinksThatArentFilms(t *testing.T) {
+
+	aggregation := Aggregation{}
+	aggregation.AddList("https://letterboxd.com/user/list/good/", lb.List{Entries: []lb.FilmListEntry{
+		{Link: "/film/film-1/", Position: 1},
+		{Link: "film-2", Position: 2},
+		{Link: "/user/", Position: 3},
+	}}, nil)
+
+	got := aggregation.RankFilms(AggregateOptions{Scoring: ScoreInclusions})
+
+	if len(got) != 1 || got[0] != "https://letterboxd.com/film/film-1/" {
+		t.Errorf("got %v, want only Film 1's url", got)
+	}
+
+	if len(aggregation.Entries) != 1 || aggregation.Entries[0].Link != "/film/film-1/" {
+		t.Errorf("got %+v, want only Film 1's entry", aggregation.Entries)
+	}
+
+	if len(aggregation.Failures) != 2 {
+		t.Errorf("got %+v, want failures for film-2 and /user/", aggregation.Failures)
+	}
+}
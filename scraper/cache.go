@@ -0,0 +1,186 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheFetcher wraps a Fetcher, storing successful responses on disk keyed by
+// url and serving them from disk until they are older than TTL. A TTL of zero
+// or less means cached responses never expire. A response that can't be
+// written to the cache is still returned, with the error kept for WriteErrors.
+type CacheFetcher struct {
+	Fetcher Fetcher
+	Dir     string
+	TTL     time.Duration
+
+	mu          sync.Mutex
+	writeErrors []error
+
+	now func() time.Time
+}
+
+// cacheEntry is the metadata stored alongside the body of a cached or
+// recorded response.
+type cacheEntry struct {
+	URL         string      `json:"url"`
+	ResponseURL string      `json:"response_url,omitempty"`
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header"`
+	FetchedAt   time.Time   `json:"fetched_at"`
+}
+
+func (f *CacheFetcher) Fetch(url string) (*Response, error) {
+
+	store := pageStore{Dir: f.Dir}
+
+	response, err := store.read(url)
+	if err == nil && checkStatus(response) == nil && (f.TTL <= 0 || f.clock().Sub(response.FetchedAt) < f.TTL) {
+		return response, nil
+	}
+
+	response, err = f.Fetcher.Fetch(url)
+	if err != nil {
+		return response, err
+	}
+
+	err = store.write(url, response, f.clock())
+	if err != nil {
+		f.recordWriteError(fmt.Errorf("error caching %s: %w", url, err))
+	}
+
+	return response, nil
+}
+
+// WriteErrors returns the errors writing responses to the cache, in the order
+// they happened.
+func (f *CacheFetcher) WriteErrors() []error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]error(nil), f.writeErrors...)
+}
+
+func (f *CacheFetcher) recordWriteError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.writeErrors = append(f.writeErrors, err)
+}
+
+func (f *CacheFetcher) clock() time.Time {
+	if f.now == nil {
+		return time.Now()
+	}
+
+	return f.now()
+}
+
+// pageStore stores responses on disk keyed by url, as the body in one file and
+// a cacheEntry in another.
+type pageStore struct {
+	Dir string
+}
+
+func (s pageStore) read(url string) (*Response, error) {
+
+	path := s.path(url)
+
+	meta, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	entry := cacheEntry{}
+	err = json.Unmarshal(meta, &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.URL != url {
+		return nil, fs.ErrNotExist
+	}
+
+	body, err := os.ReadFile(path + ".html")
+	if err != nil {
+		return nil, err
+	}
+
+	// The response url differs from the requested url when the request was
+	// redirected, as short links are.
+	responseURL := entry.ResponseURL
+	if responseURL == "" {
+		responseURL = entry.URL
+	}
+
+	return &Response{
+		URL:        responseURL,
+		StatusCode: entry.StatusCode,
+		Header:     entry.Header,
+		Body:       body,
+		FetchedAt:  entry.FetchedAt,
+	}, nil
+}
+
+func (s pageStore) write(url string, response *Response, fetchedAt time.Time) error {
+
+	err := os.MkdirAll(s.Dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(cacheEntry{
+		URL:         url,
+		ResponseURL: response.URL,
+		StatusCode:  response.StatusCode,
+		Header:      response.Header,
+		FetchedAt:   fetchedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	path := s.path(url)
+
+	err = writeFileAtomic(path+".html", response.Body)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path+".json", meta)
+}
+
+func (s pageStore) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:]))
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path so
+// readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Join(err, os.Remove(file.Name()))
+	}
+
+	return os.Rename(file.Name(), path)
+}
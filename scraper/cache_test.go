@@ -0,0 +1,130 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingFetcher counts the requests it passes on to Fetcher.
+type countingFetcher struct {
+	Fetcher Fetcher
+	Count   int
+}
+
+func (f *countingFetcher) Fetch(url string) (*Response, error) {
+	f.Count++
+	return f.Fetcher.Fetch(url)
+}
+
+func TestCacheFetcher_ServesHitsFromDisk(t *testing.T) {
+
+	inner := &countingFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/parasite/": "<html></html>"}}
+	fetcher := &CacheFetcher{Fetcher: inner, Dir: t.TempDir(), TTL: time.Hour}
+
+	for i := 0; i < 3; i++ {
+		got, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		if string(got.Body) != "<html></html>" {
+			t.Errorf("got %v, want %v", string(got.Body), "<html></html>")
+		}
+	}
+
+	if inner.Count != 1 {
+		t.Errorf("got %v requests, want %v", inner.Count, 1)
+	}
+}
+
+func TestCacheFetcher_RefetchesExpiredEntries(t *testing.T) {
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	inner := &countingFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/parasite/": "<html></html>"}}
+	fetcher := &CacheFetcher{Fetcher: inner, Dir: t.TempDir(), TTL: time.Hour, now: func() time.Time { return now }}
+
+	_, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	now = now.Add(2 * time.Hour)
+
+	_, err = fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if inner.Count != 2 {
+		t.Errorf("got %v requests, want %v", inner.Count, 2)
+	}
+}
+
+func TestCacheFetcher_DoesNotCacheErrors(t *testing.T) {
+
+	inner := &countingFetcher{Fetcher: MapFetcher{}}
+	fetcher := &CacheFetcher{Fetcher: inner, Dir: t.TempDir(), TTL: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		_, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+		if err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	}
+
+	if inner.Count != 2 {
+		t.Errorf("got %v requests, want %v", inner.Count, 2)
+	}
+}
+
+func TestCacheFetcher_ReturnsResponseWhenCacheCantBeWritten(t *testing.T) {
+
+	// A file where the cache directory should be means nothing can be cached.
+	dir := filepath.Join(t.TempDir(), "cache")
+	err := os.WriteFile(dir, nil, 0644)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	inner := &countingFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/parasite/": "<html></html>"}}
+	fetcher := &CacheFetcher{Fetcher: inner, Dir: dir, TTL: time.Hour}
+
+	got, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if string(got.Body) != "<html></html>" {
+		t.Errorf("got %v, want %v", string(got.Body), "<html></html>")
+	}
+
+	if errs := fetcher.WriteErrors(); len(errs) != 1 {
+		t.Errorf("got %v, want one write error", errs)
+	}
+}
+
+func TestCacheFetcher_KeepsRedirectedResponseUrl(t *testing.T) {
+
+	inner := &countingFetcher{Fetcher: redirectFetcher{
+		"https://boxd.it/abc1": "https://letterboxd.com/user/list/favs/",
+	}}
+	fetcher := &CacheFetcher{Fetcher: inner, Dir: t.TempDir(), TTL: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		got, err := fetcher.Fetch("https://boxd.it/abc1")
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		if got.URL != "https://letterboxd.com/user/list/favs/" {
+			t.Errorf("got %v, want %v", got.URL, "https://letterboxd.com/user/list/favs/")
+		}
+	}
+
+	if inner.Count != 1 {
+		t.Errorf("got %v requests, want %v", inner.Count, 1)
+	}
+}
@@ -0,0 +1,175 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Response is a page fetched from Letterboxd.
+type Response struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// FetchedAt is when a response served from storage, such as the cache or
+	// an archive, was fetched from Letterboxd. It's zero for a response
+	// fetched just now.
+	FetchedAt time.Time
+}
+
+// Fetcher fetches pages from Letterboxd. All scraping goes through a Fetcher
+// so the source of pages can be swapped without touching the network.
+type Fetcher interface {
+	// Fetch returns the page at url. If the page responds with a non-2xx
+	// status the response is returned along with a *StatusError.
+	Fetch(url string) (*Response, error)
+}
+
+// StatusError is returned by a Fetcher when a page responds with a non-2xx status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.URL, e.StatusCode)
+}
+
+// HTTPFetcher fetches pages from the live site over HTTP.
+type HTTPFetcher struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+// DefaultTimeout is how long to wait for a page before giving up by default.
+const DefaultTimeout = 30 * time.Second
+
+// NewHTTPFetcher returns an HTTPFetcher that gives up on a request, including
+// reading its body, after timeout, so that a stalled connection fails with an
+// error that can be retried rather than blocking forever. A timeout of 0 means
+// no timeout.
+func NewHTTPFetcher(timeout time.Duration) *HTTPFetcher {
+	return &HTTPFetcher{
+		Client:    &http.Client{Timeout: timeout},
+		UserAgent: "lb-scrape",
+	}
+}
+
+func (f *HTTPFetcher) Fetch(url string) (*Response, error) {
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.UserAgent != "" {
+		request.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	httpResponse, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	body, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{
+		URL:        httpResponse.Request.URL.String(),
+		StatusCode: httpResponse.StatusCode,
+		Header:     httpResponse.Header,
+		Body:       body,
+	}
+
+	return response, checkStatus(response)
+}
+
+// DirFetcher serves pages from a directory of fixtures. The page for a url is
+// read from the index.html file in the directory matching the url's path, so
+// https://letterboxd.com/film/parasite/ is read from <Dir>/film/parasite/index.html.
+type DirFetcher struct {
+	Dir string
+}
+
+func (f DirFetcher) Fetch(rawURL string) (*Response, error) {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(f.Dir, filepath.FromSlash(path.Clean("/"+u.Path)), "index.html")
+
+	body, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return notFound(rawURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		URL:        rawURL,
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       body,
+	}, nil
+}
+
+// MapFetcher serves pages held in memory, keyed by url.
+type MapFetcher map[string]string
+
+func (f MapFetcher) Fetch(url string) (*Response, error) {
+
+	body, exists := f[url]
+	if !exists {
+		return notFound(url)
+	}
+
+	return &Response{
+		URL:        url,
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       []byte(body),
+	}, nil
+}
+
+func notFound(url string) (*Response, error) {
+	response := &Response{
+		URL:        url,
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{},
+	}
+
+	return response, checkStatus(response)
+}
+
+func checkStatus(response *Response) error {
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &StatusError{
+			URL:        response.URL,
+			StatusCode: response.StatusCode,
+			Header:     response.Header,
+		}
+	}
+
+	return nil
+}
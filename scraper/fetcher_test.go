@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTTPFetcher_ReturnsBody(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	got, err := NewHTTPFetcher(DefaultTimeout).Fetch(server.URL + "/film/parasite/")
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if string(got.Body) != "<html></html>" {
+		t.Errorf("got %v, want %v", string(got.Body), "<html></html>")
+	}
+}
+
+func TestHTTPFetcher_ReturnsStatusErrorWhenNotOk(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPFetcher(DefaultTimeout).Fetch(server.URL + "/film/parasite/")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("got %v, want *StatusError", err)
+	}
+
+	if statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got %v, want %v", statusErr.StatusCode, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHTTPFetcher_TimesOutStalledRequests(t *testing.T) {
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	_, err := NewHTTPFetcher(50 * time.Millisecond).Fetch(server.URL + "/film/parasite/")
+	if err == nil {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+
+	if !retryable(err) {
+		t.Errorf("got %v, want a retryable error", err)
+	}
+}
+
+func TestDirFetcher_ReadsIndexFileForPath(t *testing.T) {
+
+	dir := t.TempDir()
+
+	err := os.MkdirAll(filepath.Join(dir, "film", "parasite"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "film", "parasite", "index.html"), []byte("<html></html>"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DirFetcher{Dir: dir}.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if string(got.Body) != "<html></html>" {
+		t.Errorf("got %v, want %v", string(got.Body), "<html></html>")
+	}
+}
+
+func TestDirFetcher_ReturnsStatusErrorWhenFileMissing(t *testing.T) {
+
+	_, err := DirFetcher{Dir: t.TempDir()}.Fetch("https://letterboxd.com/film/parasite/")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("got %v, want 404 *StatusError", err)
+	}
+}
+
+func TestMapFetcher_ReturnsStatusErrorWhenUrlMissing(t *testing.T) {
+
+	_, err := MapFetcher{}.Fetch("https://letterboxd.com/film/parasite/")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("got %v, want 404 *StatusError", err)
+	}
+}
@@ -0,0 +1,131 @@
+package scraper
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxDuration is the longest wait a Limiter gives, so that very low rates
+// don't overflow a time.Duration.
+const maxDuration = time.Duration(math.MaxInt64)
+
+// Limiter is a token bucket shared by every request the scraper makes. Tokens
+// are added at a rate of Rate per second up to a maximum of Burst, and each
+// request waits until it can take a token.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewLimiter returns a Limiter allowing rate requests per second on average
+// and up to burst requests at once. The bucket starts full. A rate of zero or
+// less means requests are not limited.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+	}
+}
+
+// Wait blocks until a request may be made. Waiting requests are served in the
+// order they called Wait.
+func (l *Limiter) Wait() {
+	l.mu.Lock()
+
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return
+	}
+
+	now := l.clock()
+	l.refill(now)
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = maxDuration
+		if seconds := -l.tokens / l.rate; seconds < maxDuration.Seconds() {
+			wait = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	l.mu.Unlock()
+
+	if wait > 0 {
+		l.wait(wait)
+	}
+}
+
+// Rate returns the number of requests per second the Limiter allows.
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
+
+// SetRate changes the number of requests per second the Limiter allows.
+// Requests already waiting keep the wait they were given.
+func (l *Limiter) SetRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate > 0 {
+		l.refill(l.clock())
+	}
+
+	l.rate = rate
+}
+
+func (l *Limiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+
+	l.last = now
+}
+
+func (l *Limiter) clock() time.Time {
+	if l.now == nil {
+		return time.Now()
+	}
+
+	return l.now()
+}
+
+func (l *Limiter) wait(d time.Duration) {
+	if l.sleep == nil {
+		time.Sleep(d)
+		return
+	}
+
+	l.sleep(d)
+}
+
+// LimitedFetcher wraps a Fetcher, waiting on Limiter before every request it
+// passes on. Wrapping a LimitedFetcher in a CacheFetcher means cache hits are
+// served without waiting.
+type LimitedFetcher struct {
+	Fetcher Fetcher
+	Limiter *Limiter
+}
+
+func (f *LimitedFetcher) Fetch(url string) (*Response, error) {
+	f.Limiter.Wait()
+	return f.Fetcher.Fetch(url)
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_AllowsBurstThenWaits(t *testing.T) {
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+
+	limiter := NewLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	for i := 0; i < 5; i++ {
+		limiter.Wait()
+	}
+
+	want := []time.Duration{500 * time.Millisecond, time.Second}
+	if len(slept) != len(want) || slept[0] != want[0] || slept[1] != want[1] {
+		t.Errorf("got %v, want %v", slept, want)
+	}
+}
+
+func TestLimiter_RefillsOverTime(t *testing.T) {
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+
+	limiter := NewLimiter(1, 1)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	limiter.Wait()
+	now = now.Add(time.Second)
+	limiter.Wait()
+
+	if len(slept) != 0 {
+		t.Errorf("got %v, want no waits", slept)
+	}
+}
+
+func TestLimiter_DoesNotLimitZeroRate(t *testing.T) {
+
+	var slept []time.Duration
+
+	limiter := NewLimiter(0, 1)
+	limiter.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	for i := 0; i < 5; i++ {
+		limiter.Wait()
+	}
+
+	if len(slept) != 0 {
+		t.Errorf("got %v, want no waits", slept)
+	}
+}
+
+func TestLimiter_CapsWaitAtTinyRates(t *testing.T) {
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+
+	limiter := NewLimiter(1e-300, 1)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	limiter.Wait()
+	limiter.Wait()
+
+	if len(slept) != 1 || slept[0] != maxDuration {
+		t.Errorf("got %v, want %v", slept, []time.Duration{maxDuration})
+	}
+}
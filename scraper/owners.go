@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"sort"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+// SumOwners summarises what the owner of each list contributed: how many lists
+// and distinct films, the ratings they gave those films, and how many of them
+// are among the first consensusSize of consensus, the films as scored by
+// ScoreFilms.
+// A film in more than one of an owner's lists is counted once, with the first
+// rating the owner gave it.
+func SumOwners(lists []lb.List, consensus []lb.FilmListEntry, consensusSize int) []lb.Owner {
+
+	top := map[string]bool{}
+	for i := 0; i < len(consensus) && i < consensusSize; i++ {
+		top[consensus[i].Link] = true
+	}
+
+	owners := map[string]*lb.Owner{}
+	films := map[string]map[string]lb.Rating{}
+	var order []string
+
+	for _, list := range lists {
+		owner, exists := owners[list.Owner]
+		if !exists {
+			owner = &lb.Owner{Name: list.Owner, DisplayName: list.OwnerName}
+			owners[list.Owner] = owner
+			films[list.Owner] = map[string]lb.Rating{}
+			order = append(order, list.Owner)
+		}
+
+		if owner.DisplayName == "" {
+			owner.DisplayName = list.OwnerName
+		}
+
+		owner.Lists++
+
+		for _, entry := range list.Entries {
+			rating, seen := films[list.Owner][entry.Link]
+			if seen && rating.Rated() {
+				continue
+			}
+
+			films[list.Owner][entry.Link] = entry.Rating
+			if seen {
+				continue
+			}
+
+			owner.Films++
+			if top[entry.Link] {
+				owner.ConsensusOverlap++
+			}
+		}
+	}
+
+	summary := []lb.Owner{}
+
+	for _, name := range order {
+		var ratings []lb.Rating
+		for _, rating := range films[name] {
+			if rating.Rated() {
+				ratings = append(ratings, rating)
+			}
+		}
+
+		owner := *owners[name]
+		owner.Ratings = summariseRatings(ratings)
+		summary = append(summary, owner)
+	}
+
+	sort.SliceStable(summary, func(i, j int) bool {
+		if summary[i].Films != summary[j].Films {
+			return summary[i].Films > summary[j].Films
+		}
+		return summary[i].Name < summary[j].Name
+	})
+
+	return summary
+}
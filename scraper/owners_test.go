@@ -0,0 +1,43 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+func TestSumOwners(t *testing.T) {
+	lists := []lb.List{
+		{Owner: "alice", OwnerName: "Alice", Entries: []lb.FilmListEntry{
+			{Link: "/film/parasite/", Rating: 8},
+			{Link: "/film/faust-1926/", Rating: 10},
+			{Link: "/film/nowhere/"},
+		}},
+		{Owner: "bob", Entries: []lb.FilmListEntry{
+			{Link: "/film/parasite/", Rating: 6},
+		}},
+		{Owner: "alice", OwnerName: "Alice", Entries: []lb.FilmListEntry{
+			{Link: "/film/parasite/", Rating: 4},
+			{Link: "/film/nowhere/", Rating: 7},
+		}},
+	}
+
+	consensus := []lb.FilmListEntry{
+		{Link: "/film/parasite/"},
+		{Link: "/film/faust-1926/"},
+		{Link: "/film/nowhere/"},
+	}
+
+	got := SumOwners(lists, consensus, 2)
+	want := []lb.Owner{
+		{Name: "alice", DisplayName: "Alice", Lists: 2, Films: 3, ConsensusOverlap: 2,
+			Ratings: lb.RatingStats{Count: 3, Mean: 25.0 / 3, Median: 8, Min: 7, Max: 10}},
+		{Name: "bob", Lists: 1, Films: 1, ConsensusOverlap: 1,
+			Ratings: lb.RatingStats{Count: 1, Mean: 6, Median: 6, Min: 6, Max: 6}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+// FilmResult is the outcome of scraping a single film with ScrapeFilms.
+type FilmResult struct {
+	Index int
+	Url   string
+	Film  lb.Film
+	Err   error
+
+	// CommunityErr is why the film's rating histogram or stats couldn't be
+	// scraped, leaving them empty. It doesn't fail the film.
+	CommunityErr error
+}
+
+// ScrapeFilm scrapes and parses the film page at url.
+func ScrapeFilm(fetcher Fetcher, url string) (lb.Film, error) {
+
+	html, err := ScrapeFilmHtml(fetcher, url)
+	if err != nil {
+		return lb.Film{}, err
+	}
+
+	return ParseFilm(html)
+}
+
+// ScrapeFilmCommunity scrapes the rating histogram and stats of the film at
+// url into film where Letterboxd has them. They're optional, so if either fails
+// it's left empty and the other is still scraped, and the failures are
+// returned together.
+func ScrapeFilmCommunity(fetcher Fetcher, url string, film *lb.Film) error {
+
+	var errs []error
+
+	html, err := ScrapeFilmRatingsHtml(fetcher, url)
+	if err == nil && html != "" {
+		var ratings lb.CommunityRating
+		ratings, err = ParseFilmRatings(html)
+		if err == nil {
+			if ratings.Count > 0 {
+				film.Community.Average = ratings.Average
+				film.Community.Count = ratings.Count
+			}
+			film.Community.Histogram = ratings.Histogram
+		}
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error scraping rating histogram of %s: %w", url, err))
+	}
+
+	html, err = ScrapeFilmStatsHtml(fetcher, url)
+	if err == nil && html != "" {
+		var stats lb.FilmStats
+		stats, err = ParseFilmStats(html)
+		if err == nil {
+			film.Stats = stats
+		}
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error scraping stats of %s: %w", url, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ScrapeFilms scrapes the films at urls using a pool of workers, along with
+// their rating histograms and stats unless noCommunity is set, sending the
+// results on the returned channel in the same order as urls regardless of the
+// order the workers finish in. The channel is closed once every result has
+// been sent or ctx is cancelled.
+func ScrapeFilms(ctx context.Context, fetcher Fetcher, urls []string, workers int, noCommunity bool) <-chan FilmResult {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	slots := make([]chan FilmResult, len(urls))
+	for i := range slots {
+		slots[i] = make(chan FilmResult, 1)
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range urls {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				result := FilmResult{Index: i, Url: urls[i]}
+				result.Film, result.Err = ScrapeFilm(fetcher, urls[i])
+				if result.Err == nil && !noCommunity {
+					result.CommunityErr = ScrapeFilmCommunity(fetcher, urls[i], &result.Film)
+				}
+				slots[i] <- result
+			}
+		}()
+	}
+
+	results := make(chan FilmResult)
+
+	go func() {
+		defer close(results)
+		for _, slot := range slots {
+			select {
+			case result := <-slot:
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
@@ -0,0 +1,171 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+// slowFetcher delays each response by the time in Delays for its url.
+type slowFetcher struct {
+	Fetcher Fetcher
+	Delays  map[string]time.Duration
+}
+
+func (f slowFetcher) Fetch(url string) (*Response, error) {
+	time.Sleep(f.Delays[url])
+	return f.Fetcher.Fetch(url)
+}
+
+func filmPage(title string) string {
+	return fmt.Sprintf(`<html><body><section class="film-header-group">
+		<h1 class="headline-1 filmtitle"><span class="name">%s</span></h1>
+		<div class="releaseyear"><a href="/films/year/1990/">1990</a></div>
+		<a class="contributor" href="/director/david-lynch/"><span class="prettify">David Lynch</span></a>
+	</section></body></html>`, title)
+}
+
+func TestScrapeFilms_ReturnsResultsInOrder(t *testing.T) {
+
+	urls := []string{}
+	pages := MapFetcher{}
+	delays := map[string]time.Duration{}
+
+	for i := 0; i < 6; i++ {
+		url := fmt.Sprintf("https://letterboxd.com/film/film-%d/", i)
+		urls = append(urls, url)
+		pages[url] = filmPage(fmt.Sprintf("Film %d", i))
+		delays[url] = time.Duration(6-i) * time.Millisecond
+	}
+
+	results := ScrapeFilms(context.Background(), slowFetcher{Fetcher: pages, Delays: delays}, urls, 3, false)
+
+	i := 0
+	for result := range results {
+		if result.Err != nil {
+			t.Fatalf("got %v, want %v", result.Err, nil)
+		}
+
+		want := fmt.Sprintf("Film %d", i)
+		if result.Index != i || result.Film.Title != want {
+			t.Errorf("got %v %v, want %v %v", result.Index, result.Film.Title, i, want)
+		}
+		i++
+	}
+
+	if i != len(urls) {
+		t.Errorf("got %v results, want %v", i, len(urls))
+	}
+}
+
+func TestScrapeFilms_ReturnsErrors(t *testing.T) {
+
+	results := ScrapeFilms(context.Background(), MapFetcher{}, []string{"https://letterboxd.com/film/missing/"}, 1, false)
+
+	result := <-results
+	if result.Err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestScrapeFilms_KeepsFilmsWhoseCommunityFails(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/csi/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(filmPage("Wild at Heart")))
+	}))
+	defer server.Close()
+
+	result := <-ScrapeFilms(context.Background(), NewHTTPFetcher(DefaultTimeout), []string{server.URL + "/film/wild-at-heart/"}, 1, false)
+
+	if result.Err != nil || result.Film.Title != "Wild at Heart" {
+		t.Errorf("got %q, %v, want %q", result.Film.Title, result.Err, "Wild at Heart")
+	}
+
+	if result.CommunityErr == nil {
+		t.Errorf("got %v, want an error", result.CommunityErr)
+	}
+}
+
+func TestScrapeFilms_SkipsCommunity(t *testing.T) {
+
+	inner := &countingFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/wild-at-heart/": filmPage("Wild at Heart")}}
+
+	result := <-ScrapeFilms(context.Background(), inner, []string{"https://letterboxd.com/film/wild-at-heart/"}, 1, true)
+
+	if result.Err != nil || result.CommunityErr != nil {
+		t.Errorf("got %v, %v, want no errors", result.Err, result.CommunityErr)
+	}
+
+	if inner.Count != 1 {
+		t.Errorf("got %v requests, want %v", inner.Count, 1)
+	}
+}
+
+func TestScrapeListAndFilms_AgainstTestServer(t *testing.T) {
+
+	pages := newPaginatedListFetcher(2)
+	pages["https://letterboxd.com/film/film-1/"] = filmPage("Film 1")
+	pages["https://letterboxd.com/film/film-2/"] = filmPage("Film 2")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, exists := pages[lb.DefaultBaseURL+r.URL.Path]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(DefaultTimeout)
+
+	listURL, err := lb.ParseListURL("https://letterboxd.com/user/list/test/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	list, err := ScrapeFilmList(fetcher, listURL.On(server.URL), 0)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if list.Owner != "user" || list.Pages != 2 || len(list.Entries) != 2 {
+		t.Fatalf("got %+v, want 2 entries by user from 2 pages", list)
+	}
+
+	urls := []string{}
+	for _, entry := range list.Entries {
+		filmURL, err := lb.ParseURL(entry.Link)
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		urls = append(urls, filmURL.On(server.URL))
+	}
+
+	i := 0
+	for result := range ScrapeFilms(context.Background(), fetcher, urls, 2, false) {
+		if !strings.HasPrefix(result.Url, server.URL) {
+			t.Errorf("got %v, want a url on %v", result.Url, server.URL)
+		}
+
+		want := fmt.Sprintf("Film %d", i+1)
+		if result.Err != nil || result.Film.Title != want {
+			t.Errorf("got %q, %v, want %q", result.Film.Title, result.Err, want)
+		}
+		i++
+	}
+
+	if i != 2 {
+		t.Errorf("got %v films, want %v", i, 2)
+	}
+}
@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+// ScrapeFilmRatingsHtml scrapes the rating histogram of the film at url, which
+// Letterboxd serves separately from the film page.
+func ScrapeFilmRatingsHtml(fetcher Fetcher, url string) (string, error) {
+	return scrapeFilmPartial(fetcher, url, "rating-histogram")
+}
+
+// ScrapeFilmStatsHtml scrapes the watch, list and like counts of the film at
+// url, which Letterboxd serves separately from the film page.
+func ScrapeFilmStatsHtml(fetcher Fetcher, url string) (string, error) {
+	return scrapeFilmPartial(fetcher, url, "stats")
+}
+
+// ParseFilmRatings parses the weighted average, count and histogram of the
+// ratings Letterboxd members have given a film.
+func ParseFilmRatings(content string) (lb.CommunityRating, error) {
+
+	sel := selectors.Load()
+
+	ratings := lb.CommunityRating{}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return ratings, errors.New("error creating film ratings reader")
+	}
+
+	average := doc.Find(sel.AverageRating).First()
+	if average.Length() > 0 {
+		match := weightedAverageRegexp.FindStringSubmatch(tooltip(average))
+		if match == nil {
+			return ratings, errors.New("error parsing average rating from film ratings")
+		}
+
+		ratings.Average, err = strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return ratings, errors.New("error parsing average rating from film ratings")
+		}
+
+		ratings.Count = parseCount(match[2])
+	}
+
+	bars := doc.Find(sel.HistogramBar)
+	if bars.Length() != 0 && bars.Length() != len(ratings.Histogram) {
+		return ratings, errors.New("error parsing histogram from film ratings")
+	}
+
+	bars.Each(func(i int, bar *goquery.Selection) {
+		ratings.Histogram[i] = parseCount(tooltip(bar.Find(sel.HistogramBarLink).First()))
+	})
+
+	return ratings, nil
+}
+
+// ParseFilmStats parses how many members have watched, listed and liked a film.
+func ParseFilmStats(content string) (lb.FilmStats, error) {
+
+	sel := selectors.Load()
+
+	stats := lb.FilmStats{}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return stats, errors.New("error creating film stats reader")
+	}
+
+	stats.Watches = parseCount(tooltip(doc.Find(sel.Watches).First()))
+	stats.Lists = parseCount(tooltip(doc.Find(sel.Lists).First()))
+	stats.Likes = parseCount(tooltip(doc.Find(sel.Likes).First()))
+
+	return stats, nil
+}
+
+var weightedAverageRegexp = regexp.MustCompile(`Weighted average of ([\d.]+) based on ([\d,]+)`)
+
+var countRegexp = regexp.MustCompile(`\d[\d,]*`)
+
+// parseAggregateRating parses the average and count of a film's ratings from
+// the structured data embedded in the film page, for when the rating histogram
+// isn't available.
+func parseAggregateRating(doc *goquery.Document) lb.CommunityRating {
+
+	ratings := lb.CommunityRating{}
+
+	doc.Find(selectors.Load().StructuredData).Each(func(i int, script *goquery.Selection) {
+		text := script.Text()
+		text = strings.ReplaceAll(text, "/* <![CDATA[ */", "")
+		text = strings.ReplaceAll(text, "/* ]]> */", "")
+
+		data := struct {
+			AggregateRating *struct {
+				RatingValue float64 `json:"ratingValue"`
+				RatingCount int     `json:"ratingCount"`
+			} `json:"aggregateRating"`
+		}{}
+
+		if json.Unmarshal([]byte(text), &data) != nil || data.AggregateRating == nil {
+			return
+		}
+
+		ratings.Average = data.AggregateRating.RatingValue
+		ratings.Count = data.AggregateRating.RatingCount
+	})
+
+	return ratings
+}
+
+// tooltip returns the tooltip text of a link, which is where Letterboxd puts
+// exact counts and cast members' characters.
+func tooltip(selection *goquery.Selection) string {
+	text := selection.AttrOr(selectors.Load().TooltipAttr, "")
+	if text == "" {
+		text = selection.AttrOr("title", "")
+	}
+
+	return text
+}
+
+// parseCount parses the first number in text, ignoring thousands separators,
+// or returns 0 if there is none.
+func parseCount(text string) int {
+	count, err := strconv.Atoi(strings.ReplaceAll(countRegexp.FindString(text), ",", ""))
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+// scrapeFilmPartial scrapes one of the /csi/film/<slug>/<partial>/ fragments
+// Letterboxd loads into a film page. A missing fragment, whether Letterboxd has
+// none or it wasn't stored, such as by a cache, returns an empty string.
+func scrapeFilmPartial(fetcher Fetcher, url string, partial string) (string, error) {
+
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return "", err
+	}
+
+	u.Path = "/csi/film/" + ParseSlug(u.Path) + "/" + partial + "/"
+
+	response, err := fetcher.Fetch(u.String())
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound || errors.Is(err, ErrNotStored) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(response.Body), nil
+}
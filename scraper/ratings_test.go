@@ -0,0 +1,135 @@
+package scraper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+func TestParseFilmRatings_ReturnsHistogram(t *testing.T) {
+
+	got, err := ParseFilmRatings(`
+	<section class="ratings-histogram-chart">
+		<span class="average-rating" itemprop="aggregateRating">
+			<a href="/film/wild-at-heart/ratings/" class="tooltip display-rating" data-original-title="Weighted average of 3.71 based on 98,765&nbsp;ratings">3.7</a>
+		</span>
+		<div class="rating-histogram clear rating-histogram-exploded"><ul>
+			<li class="rating-histogram-bar"><a href="/film/wild-at-heart/ratings/rated/.5/" class="ir tooltip" data-original-title="123&nbsp;half-★ ratings (0%)"></a></li>
+			<li class="rating-histogram-bar"><a href="/film/wild-at-heart/ratings/rated/1/" class="ir tooltip" data-original-title="456&nbsp;★ ratings (0%)"></a></li>
+			<li class="rating-histogram-bar"><i style="height: 1px;"></i></li>
+			<li class="rating-histogram-bar"><a class="ir tooltip" data-original-title="1,234&nbsp;★★ ratings (1%)"></a></li>
+			<li class="rating-histogram-bar"><a class="ir tooltip" data-original-title="2,345&nbsp;★★½ ratings (2%)"></a></li>
+			<li class="rating-histogram-bar"><a class="ir tooltip" data-original-title="10,000&nbsp;★★★ ratings (10%)"></a></li>
+			<li class="rating-histogram-bar"><a class="ir tooltip" data-original-title="20,000&nbsp;★★★½ ratings (20%)"></a></li>
+			<li class="rating-histogram-bar"><a class="ir tooltip" data-original-title="30,000&nbsp;★★★★ ratings (30%)"></a></li>
+			<li class="rating-histogram-bar"><a class="ir tooltip" data-original-title="20,000&nbsp;★★★★½ ratings (20%)"></a></li>
+			<li class="rating-histogram-bar"><a class="ir tooltip" data-original-title="14,607&nbsp;★★★★★ ratings (15%)"></a></li>
+		</ul></div>
+	</section>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := lb.CommunityRating{
+		Average:   3.71,
+		Count:     98765,
+		Histogram: [10]int{123, 456, 0, 1234, 2345, 10000, 20000, 30000, 20000, 14607},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseFilmRatings_ReturnsNonNilErrorWhenHistogramIncomplete(t *testing.T) {
+
+	_, err := ParseFilmRatings(`<div class="rating-histogram"><ul><li class="rating-histogram-bar"></li></ul></div>`)
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestParseFilmStats_ReturnsCounts(t *testing.T) {
+
+	got, err := ParseFilmStats(`
+	<ul class="film-stats">
+		<li class="stat filmstat-watches"><a href="/film/wild-at-heart/members/" class="tooltip" data-original-title="Watched by 234,567&nbsp;members">234K</a></li>
+		<li class="stat filmstat-lists"><a href="/film/wild-at-heart/lists/" class="tooltip" data-original-title="Appears in 45,678&nbsp;lists">45K</a></li>
+		<li class="stat filmstat-likes"><a href="/film/wild-at-heart/likes/" class="tooltip" data-original-title="Liked by 56,789&nbsp;members">56K</a></li>
+	</ul>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := lb.FilmStats{Watches: 234567, Lists: 45678, Likes: 56789}
+
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseFilm_ReturnsAggregateRating(t *testing.T) {
+
+	got, err := ParseFilm(`
+	<script type="application/ld+json">
+	/* <![CDATA[ */
+	{"@type":"Movie","aggregateRating":{"bestRating":5,"@type":"aggregateRating","ratingValue":3.71,"ratingCount":98765,"worstRating":0}}
+	/* ]]> */
+	</script>
+	<h1 class="headline-1 filmtitle"><span class="name">Wild at Heart</span></h1>
+	<div class="releaseyear"><a href="/films/year/1990/">1990</a></div>
+	<a class="contributor" href="/director/david-lynch/"><span class="prettify">David Lynch</span></a>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := lb.CommunityRating{Average: 3.71, Count: 98765}
+
+	if got.Community != want {
+		t.Errorf("got %v, want %v", got.Community, want)
+	}
+}
+
+func TestScrapeFilmCommunity_AddsRatingsAndStats(t *testing.T) {
+
+	fetcher := MapFetcher{
+		"https://letterboxd.com/csi/film/wild-at-heart/rating-histogram/": `
+			<span class="average-rating"><a data-original-title="Weighted average of 3.71 based on 98,765&nbsp;ratings">3.7</a></span>`,
+		"https://letterboxd.com/csi/film/wild-at-heart/stats/": `
+			<li class="stat filmstat-likes"><a data-original-title="Liked by 56,789&nbsp;members">56K</a></li>`,
+	}
+
+	got := lb.Film{Title: "Wild at Heart"}
+
+	err := ScrapeFilmCommunity(fetcher, "https://letterboxd.com/film/wild-at-heart/", &got)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if got.Title != "Wild at Heart" || got.Community.Average != 3.71 || got.Stats.Likes != 56789 {
+		t.Errorf("got %+v, want title, average rating and likes", got)
+	}
+}
+
+func TestScrapeFilmCommunity_LeavesFailedFragmentsEmpty(t *testing.T) {
+
+	fetcher := MapFetcher{
+		"https://letterboxd.com/csi/film/wild-at-heart/rating-histogram/": `
+			<ul><li class="rating-histogram-bar"><a data-original-title="12&nbsp;half-★ ratings">12</a></li></ul>`,
+		"https://letterboxd.com/csi/film/wild-at-heart/stats/": `
+			<li class="stat filmstat-likes"><a data-original-title="Liked by 56,789&nbsp;members">56K</a></li>`,
+	}
+
+	got := lb.Film{}
+
+	err := ScrapeFilmCommunity(fetcher, "https://letterboxd.com/film/wild-at-heart/", &got)
+	if err == nil || !strings.Contains(err.Error(), "rating histogram") {
+		t.Errorf("got %v, want a rating histogram error", err)
+	}
+
+	if got.Community != (lb.CommunityRating{}) || got.Stats.Likes != 56789 {
+		t.Errorf("got %+v, want no community rating and the likes", got)
+	}
+}
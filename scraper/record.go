@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// RecordFetcher wraps a Fetcher, storing every response it returns in Dir
+// whatever its status, in the same format as CacheFetcher, so that a scraping
+// session can be replayed with ReplayFetcher. Requests that fail without a
+// response aren't recorded.
+type RecordFetcher struct {
+	Fetcher Fetcher
+	Dir     string
+
+	now func() time.Time
+}
+
+func (f *RecordFetcher) Fetch(url string) (*Response, error) {
+
+	response, err := f.Fetcher.Fetch(url)
+	if response == nil {
+		return response, err
+	}
+
+	now := time.Now()
+	if f.now != nil {
+		now = f.now()
+	}
+
+	recordErr := pageStore{Dir: f.Dir}.write(url, response, now)
+	if recordErr != nil {
+		return response, errors.Join(err, fmt.Errorf("error recording %s: %w", url, recordErr))
+	}
+
+	return response, err
+}
+
+// ErrNotStored is returned by fetchers serving stored pages, such as
+// ReplayFetcher and WARCArchive, for pages that weren't stored.
+var ErrNotStored = errors.New("page not stored")
+
+// ReplayFetcher serves the responses recorded by RecordFetcher in Dir, with the
+// status they were recorded with. Urls that weren't recorded return
+// ErrNotStored rather than a 404 so that gaps in a recording aren't mistaken
+// for missing pages. As the format is the same, Dir may also be a cache
+// directory.
+type ReplayFetcher struct {
+	Dir string
+}
+
+func (f ReplayFetcher) Fetch(url string) (*Response, error) {
+
+	response, err := pageStore{Dir: f.Dir}.read(url)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s in %s", ErrNotStored, url, f.Dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return response, checkStatus(response)
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordFetcher_RecordsResponsesForReplay(t *testing.T) {
+
+	dir := t.TempDir()
+	recorder := &RecordFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/parasite/": "<html>Parasite</html>"}, Dir: dir}
+
+	want, err := recorder.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	got, err := ReplayFetcher{Dir: dir}.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if got.FetchedAt.IsZero() {
+		t.Errorf("got %v, want when the response was recorded", got.FetchedAt)
+	}
+
+	got.FetchedAt = time.Time{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordFetcher_ReplaysErrorStatuses(t *testing.T) {
+
+	dir := t.TempDir()
+	recorder := &RecordFetcher{Fetcher: MapFetcher{}, Dir: dir}
+
+	_, err := recorder.Fetch("https://letterboxd.com/csi/film/parasite/stats/")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %v, want a 404", err)
+	}
+
+	_, err = ReplayFetcher{Dir: dir}.Fetch("https://letterboxd.com/csi/film/parasite/stats/")
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("got %v, want a 404", err)
+	}
+}
+
+func TestReplayFetcher_ReturnsErrorForUnrecordedUrls(t *testing.T) {
+
+	_, err := ReplayFetcher{Dir: t.TempDir()}.Fetch("https://letterboxd.com/film/parasite/")
+
+	var statusErr *StatusError
+	if err == nil || errors.As(err, &statusErr) {
+		t.Errorf("got %v, want an error that isn't a status", err)
+	}
+}
+
+func TestCacheFetcher_RefetchesRecordedErrors(t *testing.T) {
+
+	dir := t.TempDir()
+	recorder := &RecordFetcher{Fetcher: &scriptedFetcher{Statuses: []int{500}}, Dir: dir}
+	recorder.Fetch("https://letterboxd.com/film/parasite/")
+
+	inner := &countingFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/parasite/": "<html></html>"}}
+	fetcher := &CacheFetcher{Fetcher: inner, Dir: dir}
+
+	_, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil || inner.Count != 1 {
+		t.Errorf("got %v after %v requests, want %v after %v", err, inner.Count, nil, 1)
+	}
+}
@@ -0,0 +1,185 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryFetcher wraps a Fetcher, retrying requests that fail with a network
+// error, a 429 or a 5xx status. Retries wait for a jittered exponential backoff
+// starting at BaseDelay and capped at MaxDelay, unless a 429 or 503 response
+// says how long to wait with a Retry-After header.
+type RetryFetcher struct {
+	Fetcher     Fetcher
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	mu      sync.Mutex
+	retries map[string]int
+
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+func (f *RetryFetcher) Fetch(url string) (*Response, error) {
+
+	var response *Response
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		response, err = f.Fetcher.Fetch(url)
+		if err == nil || !retryable(err) || attempt+1 >= f.MaxAttempts {
+			return response, err
+		}
+
+		f.recordRetry(url)
+		f.wait(f.delay(attempt, err))
+	}
+}
+
+// Retries returns the number of times each url has been retried. Urls that
+// succeeded on their first attempt are not included.
+func (f *RetryFetcher) Retries() map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	retries := map[string]int{}
+	for url, count := range f.retries {
+		retries[url] = count
+	}
+
+	return retries
+}
+
+// TotalRetries returns the number of retries made across all urls.
+func (f *RetryFetcher) TotalRetries() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	total := 0
+	for _, count := range f.retries {
+		total += count
+	}
+
+	return total
+}
+
+func (f *RetryFetcher) recordRetry(url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.retries == nil {
+		f.retries = map[string]int{}
+	}
+
+	f.retries[url]++
+}
+
+// delay returns how long to wait before retrying after the given attempt.
+func (f *RetryFetcher) delay(attempt int, err error) time.Duration {
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode == http.StatusServiceUnavailable) {
+		retryAfter, ok := parseRetryAfter(statusErr.Header.Get("Retry-After"), f.clock())
+		if ok {
+			return retryAfter
+		}
+	}
+
+	backoff := f.BaseDelay << attempt
+	if backoff <= 0 || (f.MaxDelay > 0 && backoff > f.MaxDelay) {
+		backoff = f.MaxDelay
+	}
+
+	if backoff <= 0 {
+		return 0
+	}
+
+	// Wait for between half and all of the backoff so that concurrent
+	// requests that failed together don't retry together.
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+func (f *RetryFetcher) wait(d time.Duration) {
+	if f.sleep == nil {
+		time.Sleep(d)
+		return
+	}
+
+	f.sleep(d)
+}
+
+func (f *RetryFetcher) clock() time.Time {
+	if f.now == nil {
+		return time.Now()
+	}
+
+	return f.now()
+}
+
+// retryable reports whether a failed request is worth retrying: it was
+// throttled, or failed with a network error such as a timeout, a reset
+// connection or a response cut short.
+func retryable(err error) bool {
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return throttled(err)
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	// Every error from an http.Client is a *url.Error, which is a net.Error
+	// whatever went wrong, so it's the error it wraps that decides.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() {
+			return true
+		}
+		err = urlErr.Err
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+
+	if value == "" {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if date.Before(now) {
+		return 0, true
+	}
+
+	return date.Sub(now), true
+}
@@ -0,0 +1,161 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// scriptedFetcher responds to each request with the next status in Statuses,
+// repeating the last status once they run out.
+type scriptedFetcher struct {
+	Statuses []int
+	Header   http.Header
+	Count    int
+}
+
+func (f *scriptedFetcher) Fetch(url string) (*Response, error) {
+	status := f.Statuses[min(f.Count, len(f.Statuses)-1)]
+	f.Count++
+
+	response := &Response{URL: url, StatusCode: status, Header: f.Header, Body: []byte("<html></html>")}
+	return response, checkStatus(response)
+}
+
+func TestRetryFetcher_RetriesServerErrors(t *testing.T) {
+
+	inner := &scriptedFetcher{Statuses: []int{500, 502, 200}}
+	fetcher := &RetryFetcher{Fetcher: inner, MaxAttempts: 4, BaseDelay: time.Second, sleep: func(time.Duration) {}}
+
+	_, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := map[string]int{"https://letterboxd.com/film/parasite/": 2}
+	if got := fetcher.Retries(); got["https://letterboxd.com/film/parasite/"] != 2 || len(got) != 1 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRetryFetcher_GivesUpAfterMaxAttempts(t *testing.T) {
+
+	inner := &scriptedFetcher{Statuses: []int{503}}
+	fetcher := &RetryFetcher{Fetcher: inner, MaxAttempts: 3, BaseDelay: time.Second, sleep: func(time.Duration) {}}
+
+	_, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Errorf("got %v, want *StatusError", err)
+	}
+
+	if inner.Count != 3 {
+		t.Errorf("got %v attempts, want %v", inner.Count, 3)
+	}
+}
+
+func TestRetryFetcher_DoesNotRetryClientErrors(t *testing.T) {
+
+	inner := &scriptedFetcher{Statuses: []int{404}}
+	fetcher := &RetryFetcher{Fetcher: inner, MaxAttempts: 3, BaseDelay: time.Second, sleep: func(time.Duration) {}}
+
+	_, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	if inner.Count != 1 {
+		t.Errorf("got %v attempts, want %v", inner.Count, 1)
+	}
+}
+
+func TestRetryFetcher_HonoursRetryAfter(t *testing.T) {
+
+	var slept []time.Duration
+
+	inner := &scriptedFetcher{Statuses: []int{429, 200}, Header: http.Header{"Retry-After": {"30"}}}
+	fetcher := &RetryFetcher{
+		Fetcher:     inner,
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Second,
+		sleep:       func(d time.Duration) { slept = append(slept, d) },
+	}
+
+	_, err := fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if len(slept) != 1 || slept[0] != 30*time.Second {
+		t.Errorf("got %v, want %v", slept, []time.Duration{30 * time.Second})
+	}
+}
+
+func TestRetryFetcher_BacksOffExponentially(t *testing.T) {
+
+	var slept []time.Duration
+
+	inner := &scriptedFetcher{Statuses: []int{500}}
+	fetcher := &RetryFetcher{
+		Fetcher:     inner,
+		MaxAttempts: 4,
+		BaseDelay:   time.Second,
+		MaxDelay:    3 * time.Second,
+		sleep:       func(d time.Duration) { slept = append(slept, d) },
+	}
+
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+
+	ceilings := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if len(slept) != len(ceilings) {
+		t.Fatalf("got %v, want %v delays", slept, len(ceilings))
+	}
+
+	for i, ceiling := range ceilings {
+		if slept[i] < ceiling/2 || slept[i] > ceiling {
+			t.Errorf("got %v, want between %v and %v", slept[i], ceiling/2, ceiling)
+		}
+	}
+}
+
+func TestParseRetryAfter_ParsesHttpDate(t *testing.T) {
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got, ok := parseRetryAfter("Mon, 01 Jan 2024 00:01:00 GMT", now)
+	if !ok || got != time.Minute {
+		t.Errorf("got %v, want %v", got, time.Minute)
+	}
+}
+
+func TestRetryable_OnlyRetriesThrottlingAndNetworkErrors(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"too many requests", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"not found", &StatusError{StatusCode: http.StatusNotFound}, false},
+		{"connection reset", &url.Error{Op: "Get", URL: "https://letterboxd.com/", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, true},
+		{"connection refused", &url.Error{Op: "Get", URL: "https://letterboxd.com/", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, true},
+		{"cut short", fmt.Errorf("reading body: %w", io.ErrUnexpectedEOF), true},
+		{"unsupported scheme", &url.Error{Op: "Get", URL: "ftp://letterboxd.com/", Err: errors.New("unsupported protocol scheme")}, false},
+		{"parse error", errors.New("invalid list page"), false},
+	}
+
+	for _, test := range tests {
+		if got := retryable(test.err); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
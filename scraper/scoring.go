@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"fmt"
+	"sort"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+// Scoring is a way of scoring films by how the lists including them rank them.
+// Every mode adds up a weight between 0 and 1 for each inclusion. Unranked
+// lists spread their weight evenly, giving every entry the mean weight an
+// entry of a ranked list of the same length would get.
+type Scoring string
+
+const (
+	// ScoreInclusions weights every inclusion as 1.
+	ScoreInclusions Scoring = "inclusions"
+	// ScoreBorda weights an inclusion at position p of a list of n entries
+	// as (n - p + 1) / n, so the top of every list is worth 1.
+	ScoreBorda Scoring = "borda"
+	// ScoreReciprocal weights an inclusion at position p as 1 / p.
+	ScoreReciprocal Scoring = "reciprocal"
+	// ScoreTopN weights inclusions in the top N positions of a list as 1
+	// and all others as 0.
+	ScoreTopN Scoring = "top-n"
+	// ScoreRating weights an inclusion by the list owner's rating of the
+	// film out of 10. Unrated inclusions are weighted as 0.5, the middle of
+	// the scale.
+	ScoreRating Scoring = "rating"
+)
+
+// Scorings lists every Scoring in the order they are documented.
+var Scorings = []Scoring{ScoreInclusions, ScoreBorda, ScoreReciprocal, ScoreTopN, ScoreRating}
+
+// ParseScoring returns the Scoring named name.
+func ParseScoring(name string) (Scoring, error) {
+	for _, scoring := range Scorings {
+		if string(scoring) == name {
+			return scoring, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown scoring %q, expected one of %v", name, Scorings)
+}
+
+// ScoreFilms sets the Score of each film merged by SumFilmInclusions from
+// lists and sorts the films by score, then inclusions. topN is only used by
+// ScoreTopN.
+func ScoreFilms(films []lb.FilmListEntry, lists []lb.List, scoring Scoring, topN int) []lb.FilmListEntry {
+
+	scores := map[string]float64{}
+
+	for _, list := range lists {
+		for _, entry := range list.Entries {
+			scores[entry.Link] += weight(scoring, list, entry, topN)
+		}
+	}
+
+	scored := make([]lb.FilmListEntry, len(films))
+	copy(scored, films)
+
+	for i := range scored {
+		scored[i].Score = scores[scored[i].Link]
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Inclusions > scored[j].Inclusions
+	})
+
+	return scored
+}
+
+// weight returns how much a single entry of list adds to its film's score.
+func weight(scoring Scoring, list lb.List, entry lb.FilmListEntry, topN int) float64 {
+
+	n := float64(len(list.Entries))
+	p := float64(entry.Position)
+	ranked := list.Ranked && entry.Position > 0
+
+	switch scoring {
+	case ScoreBorda:
+		if !ranked {
+			return (n + 1) / (2 * n)
+		}
+		// Entries numbered past the end of the list, as when only some of
+		// its entries were scraped, add nothing rather than a negative weight.
+		return max(n-p+1, 0) / n
+	case ScoreReciprocal:
+		if !ranked {
+			harmonic := 0.0
+			for i := 1.0; i <= n; i++ {
+				harmonic += 1 / i
+			}
+			return harmonic / n
+		}
+		return 1 / p
+	case ScoreTopN:
+		if !ranked {
+			return float64(max(min(len(list.Entries), topN), 0)) / n
+		}
+		if entry.Position <= topN {
+			return 1
+		}
+		return 0
+	case ScoreRating:
+		if !entry.Rating.Rated() {
+			return 0.5
+		}
+		return float64(entry.Rating) / 10
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,141 @@
+package scraper
+
+import (
+	"math"
+	"testing"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+func scoringLists() []lb.List {
+	return []lb.List{
+		{Ranked: true, Entries: []lb.FilmListEntry{
+			{Link: "/film/parasite/", Position: 1, Rating: 6},
+			{Link: "/film/faust-1926/", Position: 2, Rating: 10},
+			{Link: "/film/wild-at-heart/", Position: 3, Rating: 8},
+			{Link: "/film/nowhere/", Position: 4, Rating: 4},
+		}},
+		{Ranked: false, Entries: []lb.FilmListEntry{
+			{Link: "/film/faust-1926/", Position: 1, Rating: 10},
+			{Link: "/film/wild-at-heart/", Position: 2, Rating: 10},
+		}},
+		{Ranked: false, Entries: []lb.FilmListEntry{
+			{Link: "/film/nowhere/", Position: 1, Rating: lb.Unrated},
+		}},
+	}
+}
+
+func scores(films []lb.FilmListEntry) map[string]float64 {
+	got := map[string]float64{}
+	for _, film := range films {
+		got[film.Link] = film.Score
+	}
+	return got
+}
+
+func assertScores(t *testing.T, got map[string]float64, want map[string]float64) {
+	t.Helper()
+
+	for link, score := range want {
+		if math.Abs(got[link]-score) > 1e-9 {
+			t.Errorf("got %v for %v, want %v", got[link], link, score)
+		}
+	}
+}
+
+func TestScoreFilms_Inclusions(t *testing.T) {
+	lists := scoringLists()
+
+	got := ScoreFilms(SumFilmInclusions(lists), lists, ScoreInclusions, 0)
+
+	assertScores(t, scores(got), map[string]float64{
+		"/film/faust-1926/":    2,
+		"/film/wild-at-heart/": 2,
+		"/film/parasite/":      1,
+		"/film/nowhere/":       2,
+	})
+}
+
+func TestScoreFilms_Borda(t *testing.T) {
+	lists := scoringLists()
+
+	got := ScoreFilms(SumFilmInclusions(lists), lists, ScoreBorda, 0)
+
+	assertScores(t, scores(got), map[string]float64{
+		"/film/faust-1926/":    0.75 + 0.75,
+		"/film/wild-at-heart/": 0.5 + 0.75,
+		"/film/parasite/":      1,
+		"/film/nowhere/":       0.25 + 1,
+	})
+
+	if got[0].Link != "/film/faust-1926/" || got[len(got)-1].Link != "/film/parasite/" {
+		t.Errorf("got %v, want films sorted by score", got)
+	}
+}
+
+func TestScoreFilms_Reciprocal(t *testing.T) {
+	lists := scoringLists()
+
+	got := ScoreFilms(SumFilmInclusions(lists), lists, ScoreReciprocal, 0)
+
+	assertScores(t, scores(got), map[string]float64{
+		"/film/faust-1926/":    0.5 + 0.75,
+		"/film/wild-at-heart/": 1.0/3 + 0.75,
+		"/film/parasite/":      1,
+		"/film/nowhere/":       0.25 + 1,
+	})
+}
+
+func TestScoreFilms_TopN(t *testing.T) {
+	lists := scoringLists()
+
+	got := ScoreFilms(SumFilmInclusions(lists), lists, ScoreTopN, 2)
+
+	assertScores(t, scores(got), map[string]float64{
+		"/film/faust-1926/":    1 + 1,
+		"/film/wild-at-heart/": 0 + 1,
+		"/film/parasite/":      1,
+		"/film/nowhere/":       0 + 1,
+	})
+}
+
+func TestScoreFilms_Rating(t *testing.T) {
+	lists := scoringLists()
+
+	got := ScoreFilms(SumFilmInclusions(lists), lists, ScoreRating, 0)
+
+	assertScores(t, scores(got), map[string]float64{
+		"/film/faust-1926/":    2,
+		"/film/wild-at-heart/": 1.8,
+		"/film/parasite/":      0.6,
+		"/film/nowhere/":       0.4 + 0.5,
+	})
+}
+
+func TestScoreFilms_NeverScoresBelowZero(t *testing.T) {
+	lists := []lb.List{
+		{Ranked: true, Entries: []lb.FilmListEntry{
+			{Link: "/film/parasite/", Position: 1},
+			{Link: "/film/faust-1926/", Position: 5},
+		}},
+		{Ranked: false, Entries: []lb.FilmListEntry{
+			{Link: "/film/nowhere/", Position: 1},
+		}},
+	}
+
+	for _, scoring := range []Scoring{ScoreBorda, ScoreTopN} {
+		for _, film := range ScoreFilms(SumFilmInclusions(lists), lists, scoring, -1) {
+			if film.Score < 0 {
+				t.Errorf("got %v for %v with %v, want at least 0", film.Score, film.Link, scoring)
+			}
+		}
+	}
+}
+
+func TestParseScoring_ReturnsNonNilErrorWhenUnknown(t *testing.T) {
+
+	_, err := ParseScoring("popularity")
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
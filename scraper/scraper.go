@@ -1,37 +1,130 @@
 package scraper
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"math"
+	neturl "net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	lb "github.com/ellis-vester/lb-scrape/letterboxd"
-	"github.com/gocolly/colly"
 )
 
-func ScrapeFilmListHtml(url string) (string, error) {
+// ScrapeFilmListHtml scrapes a single page of a Letterboxd list, returning the
+// html of its poster list and the url of the next page, or an empty string if
+// it is the last page.
+func ScrapeFilmListHtml(fetcher Fetcher, url string) (string, string, error) {
 
-	collector := colly.NewCollector()
+	page, err := scrapeFilmListPage(fetcher, url)
+	return page.html, page.next, err
+}
+
+// ScrapeFilmListPages scrapes every page of a Letterboxd list, following the
+// /page/N/ links until the last page is reached, and returns the poster list
+// html of each page in order. If maxPages is greater than zero no more than
+// maxPages pages are scraped.
+func ScrapeFilmListPages(fetcher Fetcher, url string, maxPages int) ([]string, error) {
 
-	var err error
-	var html string
+	pages := []string{}
 
-	collector.OnHTML("ul.poster-list", func(e *colly.HTMLElement) {
-		html, err = e.DOM.Html()
+	_, err := walkFilmListPages(fetcher, url, maxPages, func(page filmListPage) error {
+		pages = append(pages, page.html)
+		return nil
 	})
 
-	err = collector.Visit(url)
+	return pages, err
+}
+
+// filmListPage is a single page of a Letterboxd list.
+type filmListPage struct {
+	number int
+	url    string
+	doc    *goquery.Document
+	html   string
+	next   string
+}
+
+func scrapeFilmListPage(fetcher Fetcher, url string) (filmListPage, error) {
+
+	page := filmListPage{url: url}
+
+	doc, response, err := fetchDocument(fetcher, url)
 	if err != nil {
-		return html, err
+		return page, err
+	}
+
+	page.doc = doc
+
+	// A page without a poster list isn't a list page, or Letterboxd's markup
+	// has changed, so it's an error rather than a page without entries.
+	posterList := doc.Find(selectors.Load().PosterList).First()
+	if posterList.Length() == 0 {
+		return page, fmt.Errorf("error scraping %s: nothing matches poster_list %q", url, selectors.Load().PosterList)
+	}
+
+	page.html, err = posterList.Html()
+	if err != nil {
+		return page, err
+	}
+
+	href, exists := doc.Find(selectors.Load().NextPage).First().Attr("href")
+	if exists && href != "" {
+		page.next, err = resolveUrl(response.URL, href)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
+}
+
+// walkFilmListPages scrapes the pages of a Letterboxd list in order, passing
+// each to visit, and returns the number of pages scraped.
+func walkFilmListPages(fetcher Fetcher, url string, maxPages int, visit func(page filmListPage) error) (int, error) {
+
+	pages := 0
+	visited := map[string]bool{}
+
+	for url != "" && !visited[url] {
+		if maxPages > 0 && pages >= maxPages {
+			break
+		}
+
+		visited[url] = true
+
+		page, err := scrapeFilmListPage(fetcher, url)
+		if err != nil {
+			return pages, err
+		}
+
+		pages++
+		page.number = pages
+
+		err = visit(page)
+		if err != nil {
+			return pages, err
+		}
+
+		url = page.next
 	}
 
-	return html, err
+	return pages, nil
 }
 
+// ParseFilmList parses the entries of a page of a Letterboxd list. Entries the
+// owner hasn't rated are given an Unrated rating. Entries in ranked lists are
+// given the Position shown beside them; entries in unranked lists are left for
+// the caller to number.
 func ParseFilmList(content string) ([]lb.FilmListEntry, error) {
 
+	sel := selectors.Load()
+
 	listEntries := []lb.FilmListEntry{}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
@@ -42,36 +135,41 @@ func ParseFilmList(content string) ([]lb.FilmListEntry, error) {
 	success := true
 	errorMessage := ""
 
-	doc.Find("li.poster-container").Each(func(i int, selection *goquery.Selection) {
+	doc.Find(sel.ListEntry).Each(func(i int, selection *goquery.Selection) {
 
 		listEntry := lb.FilmListEntry{}
 
-		rating, exists := selection.Attr("data-owner-rating")
-		if !exists || rating == "" {
-			success = false
-			errorMessage = "error parsing data-owner-rating from film list"
-			return
-		}
-
-		ratingInt, err := strconv.ParseInt(rating, 10, 64)
+		rating, err := parseOwnerRating(selection)
 		if err != nil {
 			success = false
-			errorMessage = "error parsing rating from film list"
+			errorMessage = err.Error()
 			return
 		}
 
-		listEntry.Rating = int8(ratingInt)
+		listEntry.Rating = rating
 
 		link, exists := selection.
-			Find("div.film-poster").
-			Attr("data-target-link")
+			Find(sel.ListEntryPoster).
+			Attr(sel.ListEntryLinkAttr)
 		if !exists || link == "" {
 			success = false
-			errorMessage = "error parsing data-target-link from film list" + link
+			errorMessage = "error parsing " + sel.ListEntryLinkAttr + " from film list" + link
 			return
 		}
 
 		listEntry.Link = link
+
+		number := strings.TrimSpace(selection.Find(sel.ListEntryNumber).First().Text())
+		if number != "" {
+			position, err := strconv.Atoi(number)
+			if err != nil {
+				success = false
+				errorMessage = "error parsing list-number from film list"
+				return
+			}
+			listEntry.Position = position
+		}
+
 		listEntries = append(listEntries, listEntry)
 	})
 
@@ -82,32 +180,241 @@ func ParseFilmList(content string) ([]lb.FilmListEntry, error) {
 	return listEntries, nil
 }
 
-func ParseUsername(url string) string {
-	strings.Split(url, "/")
-	return strings.Split(url, "/")[3]
+// parseOwnerRating parses the owner's rating of a list entry, which is missing,
+// empty or 0 when the owner hasn't rated the film.
+func parseOwnerRating(selection *goquery.Selection) (lb.Rating, error) {
+
+	rating := selection.AttrOr(selectors.Load().ListEntryRatingAttr, "")
+	if rating == "" {
+		return lb.Unrated, nil
+	}
+
+	ratingInt, err := strconv.ParseInt(rating, 10, 8)
+	if err != nil || ratingInt < 0 || ratingInt > 10 {
+		return lb.Unrated, errors.New("error parsing rating from film list")
+	}
+
+	return lb.Rating(ratingInt), nil
+}
+
+// ScrapeFilmList scrapes and parses every page of the Letterboxd list at url, as
+// with ScrapeFilmListPages, along with the list's title, owner and other
+// details from its first page. The owner is taken from url when the page
+// doesn't name them, and every entry is given the owner's UserName. Entries of
+// unranked lists are numbered in the order they appear across all pages.
+func ScrapeFilmList(fetcher Fetcher, url string, maxPages int) (lb.List, error) {
+
+	list := lb.List{
+		URL:     url,
+		Entries: []lb.FilmListEntry{},
+	}
+
+	pages, err := walkFilmListPages(fetcher, url, maxPages, func(page filmListPage) error {
+
+		if page.number == 1 {
+			metadata, err := parseListMetadata(page.doc)
+			if err != nil {
+				return err
+			}
+
+			metadata.URL = list.URL
+			metadata.Entries = list.Entries
+			list = metadata
+
+			if list.Owner == "" {
+				list.Owner, err = ParseUsername(url)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		entries, err := ParseFilmList(page.html)
+		if err != nil {
+			return err
+		}
+
+		// Only a list with no films has a page without entries.
+		if len(entries) == 0 && (page.number > 1 || page.next != "" || list.EntryCount > 0) {
+			return fmt.Errorf("error scraping %s: nothing matches list_entry %q", page.url, selectors.Load().ListEntry)
+		}
+
+		for i := range entries {
+			entries[i].UserName = list.Owner
+			if entries[i].Position != 0 {
+				list.Ranked = true
+			} else {
+				entries[i].Position = len(list.Entries) + i + 1
+			}
+		}
+
+		list.Entries = append(list.Entries, entries...)
+
+		return nil
+	})
+
+	list.Pages = pages
+
+	return list, err
+}
+
+// ParseListMetadata parses the title, owner and other details of a list from
+// the html of the list's first page. Details missing from the page are left
+// empty.
+func ParseListMetadata(content string) (lb.List, error) {
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return lb.List{}, errors.New("error creating list reader")
+	}
+
+	return parseListMetadata(doc)
 }
 
-func ScrapeFilmHtml(url string) (string, error) {
+func parseListMetadata(doc *goquery.Document) (lb.List, error) {
 
-	collector := colly.NewCollector()
+	sel := selectors.Load()
 
-	var err error
-	var html string
+	list := lb.List{}
 
-	collector.OnHTML("section.film-header-group", func(e *colly.HTMLElement) {
-		html, err = e.DOM.Html()
+	list.Title = strings.TrimSpace(doc.Find(sel.ListTitle).First().Text())
+	if list.Title == "" {
+		list.Title = strings.TrimSpace(doc.Find(sel.ListTitleMeta).AttrOr(sel.MetaContentAttr, ""))
+	}
+
+	owner := doc.Find(sel.ListOwner).First()
+	list.Owner = ParseSlug(owner.AttrOr("href", ""))
+	list.OwnerName = strings.TrimSpace(owner.Text())
+
+	list.Description = strings.TrimSpace(doc.Find(sel.ListDescription).First().Text())
+
+	doc.Find(sel.ListTags).Each(func(i int, tag *goquery.Selection) {
+		list.Tags = append(list.Tags, strings.TrimSpace(tag.Text()))
 	})
 
-	err = collector.Visit(url)
+	times := doc.Find(sel.ListDates)
+	for i, field := range []*time.Time{&list.Published, &list.Updated} {
+		datetime := times.Eq(i).AttrOr(sel.ListDateAttr, "")
+		if datetime == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, datetime)
+		if err != nil {
+			return list, errors.New("error parsing date from list")
+		}
+		*field = parsed
+	}
+
+	list.Likes = parseCount(doc.Find(sel.ListLikes).First().Text())
+	list.Comments = parseCount(doc.Find(sel.ListComments).First().Text())
+
+	match := entryCountRegexp.FindStringSubmatch(doc.Find(sel.ListSummaryMeta).AttrOr(sel.MetaContentAttr, ""))
+	if match != nil {
+		list.EntryCount = parseCount(match[1])
+	}
+
+	return list, nil
+}
+
+var entryCountRegexp = regexp.MustCompile(`list of ([\d,]+) films?`)
+
+// ParseUsername parses the username of the member a Letterboxd list or user
+// url belongs to. Only the url's path is used, so the url may be on a mirror of
+// Letterboxd as well as the live site.
+func ParseUsername(url string) (string, error) {
+
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Host == "" {
+		return "", errors.New("error parsing username from url " + url)
+	}
+
+	u, err := lb.ParseURL(parsed.Path)
 	if err != nil {
-		return html, err
+		return "", err
+	}
+
+	if u.Username == "" {
+		return "", errors.New("error parsing username from url " + url)
 	}
 
-	return html, err
+	return u.Username, nil
+}
+
+// ResolveURL resolves a boxd.it short link by fetching it and parsing the url
+// it redirects to. Other urls are returned as they are. Short links only resolve
+// on the live site, so they're rejected when scraping another baseURL rather
+// than fetched from boxd.it.
+func ResolveURL(fetcher Fetcher, u lb.URL, baseURL string) (lb.URL, error) {
+
+	if u.Kind != lb.ShortURL {
+		return u, nil
+	}
+
+	if baseURL != "" && baseURL != lb.DefaultBaseURL {
+		return u, fmt.Errorf("error resolving %s: short links can't be resolved on %s, use the full url instead", u, baseURL)
+	}
+
+	response, err := fetcher.Fetch(u.String())
+	if err != nil {
+		return u, err
+	}
+
+	resolved, err := lb.ParseURL(response.URL)
+	if err != nil {
+		return u, fmt.Errorf("error resolving %s: %w", u, err)
+	}
+
+	if resolved.Kind == lb.ShortURL {
+		return u, fmt.Errorf("error resolving %s: it did not redirect", u)
+	}
+
+	return resolved, nil
+}
+
+// ResolveLists resolves the short links among lists, as ResolveURL does, and
+// returns the first page of each list, so that bad input is reported before any
+// list is scraped. Urls that aren't lists are an error.
+func ResolveLists(fetcher Fetcher, lists []lb.URL, baseURL string) ([]lb.URL, error) {
+
+	resolved := make([]lb.URL, 0, len(lists))
+
+	for _, list := range lists {
+		u, err := ResolveURL(fetcher, list, baseURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if u.Kind != lb.ListURL {
+			return nil, fmt.Errorf("%s is a %s url, not a list", list, u.Kind)
+		}
+
+		resolved = append(resolved, u.FirstPage())
+	}
+
+	return resolved, nil
+}
+
+// ScrapeFilmHtml scrapes the html of a film page. The whole page is returned
+// because the film's details are spread across the header and the tabs below it.
+func ScrapeFilmHtml(fetcher Fetcher, url string) (string, error) {
+
+	doc, _, err := fetchDocument(fetcher, url)
+	if err != nil {
+		return "", err
+	}
+
+	return doc.Html()
 }
 
 func ParseFilm(content string) (lb.Film, error) {
 
+	sel := selectors.Load()
+
 	film := lb.Film{}
 
 	success := true
@@ -118,8 +425,8 @@ func ParseFilm(content string) (lb.Film, error) {
 		return film, errors.New("error creating film reader")
 	}
 
-	titleSel := doc.Find("h1.filmtitle").Each(func(i int, selection *goquery.Selection) {
-		title := selection.Find("span").Text()
+	titleSel := doc.Find(sel.FilmTitle).Each(func(i int, selection *goquery.Selection) {
+		title := selection.Find(sel.FilmTitleName).Text()
 		if title == "" {
 			success = false
 			errorMessage = "error parsing title from film"
@@ -132,8 +439,8 @@ func ParseFilm(content string) (lb.Film, error) {
 		errorMessage = "error parsing title from film"
 	}
 
-	yearSel := doc.Find("div.releaseyear").Each(func(i int, selection *goquery.Selection) {
-		yearText := selection.Find("a").Text()
+	yearSel := doc.Find(sel.ReleaseYear).Each(func(i int, selection *goquery.Selection) {
+		yearText := selection.Find(sel.ReleaseYearLink).Text()
 		if yearText == "" {
 			success = false
 			errorMessage = "error parsing year from film"
@@ -153,20 +460,65 @@ func ParseFilm(content string) (lb.Film, error) {
 		errorMessage = "error parsing year from film"
 	}
 
-	directorSel := doc.Find("a.contributor").Each(func(i int, selection *goquery.Selection) {
-		director := selection.Find("span").Text()
+	directorSel := doc.Find(sel.Director).Each(func(i int, selection *goquery.Selection) {
+		director := selection.Find(sel.DirectorName).Text()
 		if director == "" {
 			success = false
 			errorMessage = "error parsing director from film"
 			return
 		}
-		film.Director = director
+
+		href, _ := selection.Attr("href")
+
+		film.Directors = append(film.Directors, lb.Contributor{
+			Name: director,
+			Slug: ParseSlug(href),
+		})
 	})
 	if directorSel.Length() == 0 {
 		success = false
 		errorMessage = "error parsing director from film"
 	}
 
+	film.OriginalTitle = strings.TrimSpace(doc.Find(sel.OriginalTitle).First().Text())
+
+	runtime := runtimeRegexp.FindStringSubmatch(doc.Find(sel.FilmFooter).First().Text())
+	if runtime != nil {
+		minutes, err := strconv.Atoi(runtime[1])
+		if err != nil {
+			success = false
+			errorMessage = "error parsing runtime from film"
+		}
+		film.Runtime = minutes
+	}
+
+	genres := parseTabSections(doc, sel.GenresTab)
+	film.Genres = slugTexts(genres["genre"])
+	film.Themes = slugTexts(genres["theme"])
+
+	details := parseTabSections(doc, sel.DetailsTab)
+	film.Studios = slugTexts(details["studio"])
+	film.Countries = slugTexts(details["country"])
+	film.SpokenLanguages = slugTexts(details["spoken language"])
+
+	primaryLanguages := slugTexts(details["primary language"])
+	if len(primaryLanguages) == 0 {
+		primaryLanguages = slugTexts(details["language"])
+	}
+	if len(primaryLanguages) > 0 {
+		film.PrimaryLanguage = primaryLanguages[0]
+	}
+
+	film.Cast = parseCast(doc)
+	film.Crew = parseCrew(doc)
+	film.Community = parseAggregateRating(doc)
+
+	err = parseIdentifiers(doc, &film)
+	if err != nil {
+		success = false
+		errorMessage = err.Error()
+	}
+
 	if !success {
 		return film, errors.New(errorMessage)
 	}
@@ -174,59 +526,413 @@ func ParseFilm(content string) (lb.Film, error) {
 	return film, nil
 }
 
-func SumFilmInclusions(lists [][]lb.FilmListEntry) []lb.FilmListEntry {
+// parseCast returns the actors listed in the cast tab of a film page in
+// billing order.
+func parseCast(doc *goquery.Document) []lb.CastMember {
+
+	var cast []lb.CastMember
+
+	doc.Find(selectors.Load().Cast).Each(func(i int, link *goquery.Selection) {
+		href, _ := link.Attr("href")
+		name := strings.TrimSpace(link.Text())
+		if name == "" {
+			return
+		}
+
+		character := tooltip(link)
+
+		cast = append(cast, lb.CastMember{
+			Contributor: lb.Contributor{Name: name, Slug: ParseSlug(href)},
+			Character:   strings.TrimSpace(character),
+			Billing:     len(cast) + 1,
+		})
+	})
+
+	return cast
+}
+
+// parseCrew returns the people listed in the crew tab of a film page, taking
+// each person's role from the first segment of their link.
+func parseCrew(doc *goquery.Document) []lb.CrewMember {
+
+	sel := selectors.Load()
+
+	var crew []lb.CrewMember
+
+	doc.Find(sel.Crew).Each(func(i int, link *goquery.Selection) {
+		href, _ := link.Attr("href")
+		name := strings.TrimSpace(link.Text())
+		segments := strings.Split(strings.Trim(href, "/"), "/")
+		if name == "" || len(segments) != 2 {
+			return
+		}
+
+		crew = append(crew, lb.CrewMember{
+			Contributor: lb.Contributor{Name: name, Slug: segments[1]},
+			Role:        segments[0],
+		})
+	})
+
+	return crew
+}
+
+// parseIdentifiers parses the film's Letterboxd id and slug and the ids of the
+// film on TMDB and IMDb from the film page. Films missing from TMDB or IMDb
+// are left without those ids.
+func parseIdentifiers(doc *goquery.Document, film *lb.Film) error {
+
+	sel := selectors.Load()
+
+	poster := doc.Find(sel.FilmPoster).First()
+	if id := poster.AttrOr(sel.FilmIDAttr, ""); id != "" {
+		letterboxdID, err := strconv.Atoi(id)
+		if err != nil {
+			return errors.New("error parsing letterboxd id from film")
+		}
+		film.LetterboxdID = letterboxdID
+	}
+
+	film.Slug = poster.AttrOr(sel.FilmSlugAttr, "")
+	if film.Slug == "" {
+		if url, exists := doc.Find(sel.FilmURLMeta).Attr(sel.MetaContentAttr); exists {
+			film.Slug = ParseSlug(url)
+		}
+	}
+
+	tmdbIDs := doc.Find(sel.TmdbIDs).First()
+	tmdbID := tmdbIDs.AttrOr(sel.TmdbIDAttr, "")
+	film.TmdbType = tmdbIDs.AttrOr(sel.TmdbTypeAttr, "")
+
+	if tmdbID == "" {
+		href := doc.Find(sel.TmdbLink).First().AttrOr("href", "")
+		if match := tmdbRegexp.FindStringSubmatch(href); match != nil {
+			film.TmdbType = match[1]
+			tmdbID = match[2]
+		}
+	}
+
+	if tmdbID != "" {
+		id, err := strconv.Atoi(tmdbID)
+		if err != nil {
+			return errors.New("error parsing tmdb id from film")
+		}
+		film.TmdbID = id
+	}
+
+	href := doc.Find(sel.ImdbLink).First().AttrOr("href", "")
+	film.ImdbID = imdbRegexp.FindString(href)
+
+	return nil
+}
+
+var tmdbRegexp = regexp.MustCompile(`themoviedb\.org/(movie|tv)/(\d+)`)
+
+var imdbRegexp = regexp.MustCompile(`tt\d+`)
+
+var runtimeRegexp = regexp.MustCompile(`(\d+)[\s\x{00a0}]*mins?`)
+
+// parseTabSections returns the links listed under each heading of a tab on a
+// film page, such as the genres under "Genres" in #tab-genres. Headings are
+// keyed in lower case with any plural removed, so "Countries" is "country".
+func parseTabSections(doc *goquery.Document, tab string) map[string]*goquery.Selection {
+
+	sections := map[string]*goquery.Selection{}
+
+	sel := selectors.Load()
+
+	doc.Find(tab).Find(sel.TabHeading).Each(func(i int, heading *goquery.Selection) {
+		name := strings.TrimSpace(heading.Find(sel.TabHeadingName).First().Text())
+		if name == "" {
+			name = strings.TrimSpace(heading.Text())
+		}
+
+		list := heading.Next()
+		if !list.Is(sel.TabSection) {
+			return
+		}
+
+		sections[singular(strings.ToLower(name))] = list.Find(sel.TabLink)
+	})
+
+	return sections
+}
+
+// slugTexts returns the text of each link in a tab section, skipping links
+// back to the film itself such as "Show All…".
+func slugTexts(selection *goquery.Selection) []string {
+
+	var texts []string
+	if selection == nil {
+		return texts
+	}
+
+	selection.Each(func(i int, link *goquery.Selection) {
+		href, _ := link.Attr("href")
+		text := strings.TrimSpace(link.Text())
+		if text == "" || strings.HasPrefix(href, "/film/") {
+			return
+		}
+		texts = append(texts, text)
+	})
+
+	return texts
+}
+
+func singular(name string) string {
+	if strings.HasSuffix(name, "ies") {
+		return strings.TrimSuffix(name, "ies") + "y"
+	}
+
+	return strings.TrimSuffix(name, "s")
+}
+
+// SumFilmInclusions merges the entries of lists, counting and recording the
+// lists each film is included in and summarising the ratings their owners gave
+// it. A merged entry's Rating is the rounded mean of those ratings, its Position
+// is the best rank the film reached in a ranked list, or 0 if it was never
+// ranked, and its UserName is the owner of the first list including it.
+func SumFilmInclusions(lists []lb.List) []lb.FilmListEntry {
 
 	var films = map[string]*lb.FilmListEntry{}
+	var ratings = map[string][]lb.Rating{}
 
 	for _, list := range lists {
-		for _, listItem := range list {
+		for _, listItem := range list.Entries {
 
 			var inclusions int
+			var position int
+			var inclusionsFrom []lb.Inclusion
+			var userName = listItem.UserName
 
-			_, exists := films[listItem.Link]
+			existing, exists := films[listItem.Link]
 			if exists {
-				inclusions = films[listItem.Link].Inclusions + 1
-				films[listItem.Link] = &listItem
-				films[listItem.Link].Inclusions = inclusions
+				inclusions = existing.Inclusions + 1
+				position = existing.Position
+				inclusionsFrom = existing.Lists
+				userName = existing.UserName
 			} else {
-				films[listItem.Link] = &listItem
-				films[listItem.Link].Inclusions = 1
+				inclusions = 1
+			}
+
+			if list.Ranked && (position == 0 || listItem.Position < position) {
+				position = listItem.Position
+			}
+
+			if listItem.Rating.Rated() {
+				ratings[listItem.Link] = append(ratings[listItem.Link], listItem.Rating)
+			}
+
+			inclusion := lb.Inclusion{
+				ListURL:   list.URL,
+				ListTitle: list.Title,
+				Owner:     list.Owner,
+				Position:  listItem.Position,
+				Ranked:    list.Ranked,
+				Rating:    listItem.Rating,
 			}
+
+			films[listItem.Link] = &listItem
+			films[listItem.Link].Inclusions = inclusions
+			films[listItem.Link].Position = position
+			films[listItem.Link].Lists = append(inclusionsFrom, inclusion)
+			films[listItem.Link].UserName = userName
 		}
 	}
 
 	filmListEntries := []lb.FilmListEntry{}
 
-	for _, value := range films {
+	for link, value := range films {
+		value.OwnerRatings = summariseRatings(ratings[link])
+		value.Rating = lb.Rating(math.Round(value.OwnerRatings.Mean))
 		filmListEntries = append(filmListEntries, *value)
 	}
 
 	sort.Slice(filmListEntries, func(i, j int) bool {
-		return filmListEntries[i].Inclusions > filmListEntries[j].Inclusions
+		if filmListEntries[i].Inclusions != filmListEntries[j].Inclusions {
+			return filmListEntries[i].Inclusions > filmListEntries[j].Inclusions
+		}
+		return filmListEntries[i].Link < filmListEntries[j].Link
 	})
 
 	return filmListEntries
 }
 
+// MergeListEntry returns film with the inclusions, owner ratings, score and
+// other details SumFilmInclusions and ScoreFilms aggregated into entry.
+func MergeListEntry(film lb.Film, entry lb.FilmListEntry) lb.Film {
+	film.Rating = entry.Rating
+	film.UserName = entry.UserName
+	film.Inclusions = entry.Inclusions
+	film.Link = entry.Link
+	film.BestPosition = entry.Position
+	film.Score = entry.Score
+	film.OwnerRatings = entry.OwnerRatings
+	film.Lists = entry.Lists
+
+	return film
+}
+
+// summariseRatings returns the count, mean, median, min and max of ratings.
+func summariseRatings(ratings []lb.Rating) lb.RatingStats {
+
+	stats := lb.RatingStats{Count: len(ratings)}
+	if len(ratings) == 0 {
+		return stats
+	}
+
+	sorted := make([]lb.Rating, len(ratings))
+	copy(sorted, ratings)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	sum := 0
+	for _, rating := range sorted {
+		sum += int(rating)
+	}
+
+	middle := len(sorted) / 2
+
+	stats.Mean = float64(sum) / float64(len(sorted))
+	stats.Median = float64(sorted[middle])
+	if len(sorted)%2 == 0 {
+		stats.Median = float64(sorted[middle-1]+sorted[middle]) / 2
+	}
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+
+	return stats
+}
+
+// SumDirectorInclusions counts the films each director is credited on,
+// crediting every director of a co-directed film.
 func SumDirectorInclusions(list []lb.Film) []lb.Director {
-	var directorsMap = map[string]int{}
+	var directorsMap = map[string]*lb.Director{}
 
 	for _, listItem := range list {
-		directorsMap[listItem.Director]++
+		for _, contributor := range listItem.Directors {
+			key := contributor.Slug
+			if key == "" {
+				key = contributor.Name
+			}
+
+			director, exists := directorsMap[key]
+			if !exists {
+				director = &lb.Director{
+					Name: contributor.Name,
+					Slug: contributor.Slug,
+				}
+				directorsMap[key] = director
+			}
+
+			director.Inclusions++
+		}
 	}
 
 	var directors = []lb.Director{}
 
-	for key, value := range directorsMap {
-		directors = append(directors, lb.Director{
-			Name:       key,
-			Inclusions: value,
-		})
+	for _, value := range directorsMap {
+		directors = append(directors, *value)
 	}
 
 	sort.Slice(directors, func(i, j int) bool {
-		return directors[i].Inclusions > directors[j].Inclusions
+		if directors[i].Inclusions != directors[j].Inclusions {
+			return directors[i].Inclusions > directors[j].Inclusions
+		}
+		return directors[i].Name < directors[j].Name
 	})
 
 	return directors
 }
+
+// SumPeopleInclusions counts the films each person is credited on in each
+// role, with the cast credited in the role "actor". A person credited more
+// than once in the same role on a film is only counted once for it.
+func SumPeopleInclusions(list []lb.Film) []lb.Person {
+	var peopleMap = map[string]*lb.Person{}
+
+	credit := func(counted map[string]bool, contributor lb.Contributor, role string) {
+		key := role + "/" + contributor.Slug
+		if counted[key] {
+			return
+		}
+		counted[key] = true
+
+		person, exists := peopleMap[key]
+		if !exists {
+			person = &lb.Person{
+				Name: contributor.Name,
+				Slug: contributor.Slug,
+				Role: role,
+			}
+			peopleMap[key] = person
+		}
+
+		person.Inclusions++
+	}
+
+	for _, listItem := range list {
+		counted := map[string]bool{}
+
+		for _, castMember := range listItem.Cast {
+			credit(counted, castMember.Contributor, "actor")
+		}
+
+		for _, crewMember := range listItem.Crew {
+			credit(counted, crewMember.Contributor, crewMember.Role)
+		}
+	}
+
+	var people = []lb.Person{}
+
+	for _, value := range peopleMap {
+		people = append(people, *value)
+	}
+
+	sort.Slice(people, func(i, j int) bool {
+		if people[i].Inclusions != people[j].Inclusions {
+			return people[i].Inclusions > people[j].Inclusions
+		}
+		if people[i].Role != people[j].Role {
+			return people[i].Role < people[j].Role
+		}
+		return people[i].Name < people[j].Name
+	})
+
+	return people
+}
+
+// ParseSlug returns the last segment of a Letterboxd path, such as
+// "david-lynch" for "/director/david-lynch/".
+func ParseSlug(href string) string {
+	segments := strings.Split(strings.Trim(href, "/"), "/")
+	return segments[len(segments)-1]
+}
+
+func fetchDocument(fetcher Fetcher, url string) (*goquery.Document, *Response, error) {
+
+	response, err := fetcher.Fetch(url)
+	if err != nil {
+		return nil, response, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(response.Body))
+	if err != nil {
+		return nil, response, errors.New("error creating reader for " + url)
+	}
+
+	return doc, response, nil
+}
+
+func resolveUrl(base string, ref string) (string, error) {
+
+	baseUrl, err := neturl.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	refUrl, err := neturl.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return baseUrl.ResolveReference(refUrl).String(), nil
+}
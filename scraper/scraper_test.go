@@ -1,8 +1,12 @@
 package scraper
 
 import (
+	"fmt"
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	lb "github.com/ellis-vester/lb-scrape/letterboxd"
 )
@@ -26,17 +30,49 @@ func TestParseFilmList_ReturnsValidFilmListEntry(t *testing.T) {
 	}
 }
 
-func TestParseFilmList_ReturnsNonNilErrorWhenRatingEmpty(t *testing.T) {
+func TestParseFilmList_ReturnsUnratedEntryWhenRatingEmpty(t *testing.T) {
 
-	_, err := ParseFilmList(`<li class="poster-container" data-owner-rating=""> <div class="film-poster" data-target-link="/film/faust-1926/"></div></li>`)
-	if err == nil {
-		t.Errorf("Expected error, got nil")
+	got, err := ParseFilmList(`<li class="poster-container" data-owner-rating=""> <div class="film-poster" data-target-link="/film/faust-1926/"></div></li>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := []lb.FilmListEntry{{Rating: lb.Unrated, Link: "/film/faust-1926/"}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseFilmList_ReturnsUnratedEntryWhenRatingNotPresent(t *testing.T) {
+
+	got, err := ParseFilmList(`<li class="poster-container"> <div class="film-poster" data-target-link="/film/faust-1926/"></div></li>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := []lb.FilmListEntry{{Rating: lb.Unrated, Link: "/film/faust-1926/"}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
 	}
 }
 
-func TestParseFilmList_ReturnsNonNilErrorWhenRatingNotPresent(t *testing.T) {
+func TestParseFilmList_ReturnsUnratedEntryWhenRatingZero(t *testing.T) {
 
-	_, err := ParseFilmList(`<li class="poster-container"> <div class="film-poster" data-target-link="/film/faust-1926/"></div></li>`)
+	got, err := ParseFilmList(`<li class="poster-container" data-owner-rating="0"> <div class="film-poster" data-target-link="/film/faust-1926/"></div></li>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if len(got) != 1 || got[0].Rating.Rated() {
+		t.Errorf("got %v, want one unrated entry", got)
+	}
+}
+
+func TestParseFilmList_ReturnsNonNilErrorWhenRatingOutOfRange(t *testing.T) {
+
+	_, err := ParseFilmList(`<li class="poster-container" data-owner-rating="11"> <div class="film-poster" data-target-link="/film/faust-1926/"></div></li>`)
 	if err == nil {
 		t.Errorf("Expected error, got nil")
 	}
@@ -66,6 +102,261 @@ func TestParseFilmList_ReturnsNonNilErrorWhenLinkNotPresent(t *testing.T) {
 	}
 }
 
+func newPaginatedListFetcher(pages int) MapFetcher {
+	fetcher := MapFetcher{}
+
+	for page := 1; page <= pages; page++ {
+		url := "https://letterboxd.com/user/list/test/"
+		if page > 1 {
+			url = fmt.Sprintf("https://letterboxd.com/user/list/test/page/%d/", page)
+		}
+
+		next := ""
+		if page < pages {
+			next = fmt.Sprintf(`<div class="pagination"><div class="paginate-nextprev"><a class="next" href="/user/list/test/page/%d/">Older</a></div></div>`, page+1)
+		}
+
+		fetcher[url] = fmt.Sprintf(`<html><body>
+			<ul class="poster-list">
+				<li class="poster-container" data-owner-rating="10"> <div class="film-poster" data-target-link="/film/film-%d/"></div></li>
+			</ul>
+			%s
+		</body></html>`, page, next)
+	}
+
+	return fetcher
+}
+
+func TestScrapeFilmListPages_FollowsPagination(t *testing.T) {
+
+	fetcher := newPaginatedListFetcher(3)
+
+	pages, err := ScrapeFilmListPages(fetcher, "https://letterboxd.com/user/list/test/", 0)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if len(pages) != 3 {
+		t.Fatalf("got %v pages, want %v", len(pages), 3)
+	}
+
+	for i, page := range pages {
+		want := fmt.Sprintf("/film/film-%d/", i+1)
+		if !strings.Contains(page, want) {
+			t.Errorf("page %v does not contain %v", i+1, want)
+		}
+	}
+}
+
+func TestScrapeFilmListPages_StopsAtMaxPages(t *testing.T) {
+
+	fetcher := newPaginatedListFetcher(3)
+
+	pages, err := ScrapeFilmListPages(fetcher, "https://letterboxd.com/user/list/test/", 2)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if len(pages) != 2 {
+		t.Errorf("got %v pages, want %v", len(pages), 2)
+	}
+}
+
+func TestScrapeFilmListPages_ReturnsNonNilErrorWhenPageMissing(t *testing.T) {
+
+	fetcher := newPaginatedListFetcher(3)
+	delete(fetcher, "https://letterboxd.com/user/list/test/page/3/")
+
+	_, err := ScrapeFilmListPages(fetcher, "https://letterboxd.com/user/list/test/", 0)
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestParseFilmList_ReturnsListNumbers(t *testing.T) {
+
+	got, err := ParseFilmList(`
+		<li class="poster-container numbered-list-item" data-owner-rating="10"> <div class="film-poster" data-target-link="/film/faust-1926/"></div><p class="list-number">101</p></li>
+		<li class="poster-container numbered-list-item" data-owner-rating="8"> <div class="film-poster" data-target-link="/film/parasite/"></div><p class="list-number">102</p></li>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := []lb.FilmListEntry{
+		{Rating: 10, Link: "/film/faust-1926/", Position: 101},
+		{Rating: 8, Link: "/film/parasite/", Position: 102},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestScrapeFilmList_NumbersUnrankedEntriesAcrossPages(t *testing.T) {
+
+	got, err := ScrapeFilmList(newPaginatedListFetcher(3), "https://letterboxd.com/user/list/test/", 0)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if got.Ranked || got.Pages != 3 || len(got.Entries) != 3 {
+		t.Fatalf("got %+v, want 3 unranked entries from 3 pages", got)
+	}
+
+	for i, entry := range got.Entries {
+		if entry.Position != i+1 || entry.Link != fmt.Sprintf("/film/film-%d/", i+1) {
+			t.Errorf("got %v at %v, want /film/film-%v/ at %v", entry.Link, entry.Position, i+1, i+1)
+		}
+	}
+}
+
+func TestScrapeFilmList_MarksNumberedListsRanked(t *testing.T) {
+
+	fetcher := MapFetcher{
+		"https://letterboxd.com/user/list/ranked/": `<ul class="poster-list">
+			<li class="poster-container numbered-list-item" data-owner-rating="10"> <div class="film-poster" data-target-link="/film/faust-1926/"></div><p class="list-number">1</p></li>
+		</ul>`,
+	}
+
+	got, err := ScrapeFilmList(fetcher, "https://letterboxd.com/user/list/ranked/", 0)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if !got.Ranked || got.Entries[0].Position != 1 {
+		t.Errorf("got %+v, want a ranked list", got)
+	}
+}
+
+func TestScrapeFilmList_TakesOwnerFromUrlAndSetsUserNames(t *testing.T) {
+
+	got, err := ScrapeFilmList(newPaginatedListFetcher(2), "https://letterboxd.com/user/list/test/", 0)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if got.Owner != "user" {
+		t.Errorf("got owner %q, want %q", got.Owner, "user")
+	}
+
+	for _, entry := range got.Entries {
+		if entry.UserName != "user" {
+			t.Errorf("got user name %q for %v, want %q", entry.UserName, entry.Link, "user")
+		}
+	}
+}
+
+const listPage = `<html><head>
+	<meta property="og:title" content="Favourite Films of 2023">
+	<meta name="description" content="A list of 2 films compiled on Letterboxd, including Past Lives and Anatomy of a Fall.">
+</head><body>
+	<div class="list-title-intro">
+		<span class="context">List by</span>
+		<a class="name" href="/somebody/"><span itemprop="name">Some Body</span></a>
+		<p class="list-date">
+			Published <time datetime="2023-12-31T12:00:00.000Z">31 Dec 2023</time>
+			Updated <time datetime="2024-01-02T08:30:00.000Z">2 Jan 2024</time>
+		</p>
+		<h1 class="title-1 prettify">Favourite Films of 2023</h1>
+		<div class="body-text -prose -reset js-collapsible-text"><p>My favourites from last year.</p></div>
+		<ul class="tags clear">
+			<li><a href="/somebody/tag/2023/lists/">2023</a></li>
+			<li><a href="/somebody/tag/favourites/lists/">favourites</a></li>
+		</ul>
+		<a href="/somebody/list/favourite-films-of-2023/likes/">1,234 likes</a>
+	</div>
+	<ul class="poster-list">
+		<li class="poster-container" data-owner-rating="10"> <div class="film-poster" data-target-link="/film/past-lives/"></div></li>
+		<li class="poster-container" data-owner-rating="9"> <div class="film-poster" data-target-link="/film/anatomy-of-a-fall/"></div></li>
+	</ul>
+	<section id="comments"><h2 class="section-heading">56 comments</h2></section>
+</body></html>`
+
+func TestParseListMetadata_ReturnsListDetails(t *testing.T) {
+
+	got, err := ParseListMetadata(listPage)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := lb.List{
+		Title:       "Favourite Films of 2023",
+		Owner:       "somebody",
+		OwnerName:   "Some Body",
+		Description: "My favourites from last year.",
+		Tags:        []string{"2023", "favourites"},
+		Published:   time.Date(2023, 12, 31, 12, 0, 0, 0, time.UTC),
+		Updated:     time.Date(2024, 1, 2, 8, 30, 0, 0, time.UTC),
+		Likes:       1234,
+		Comments:    56,
+		EntryCount:  2,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestScrapeFilmList_ReturnsMetadataAndEntries(t *testing.T) {
+
+	fetcher := MapFetcher{"https://letterboxd.com/somebody/list/favourite-films-of-2023/": listPage}
+
+	got, err := ScrapeFilmList(fetcher, "https://letterboxd.com/somebody/list/favourite-films-of-2023/", 0)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if got.URL != "https://letterboxd.com/somebody/list/favourite-films-of-2023/" || got.Title != "Favourite Films of 2023" || len(got.Entries) != 2 || got.Pages != 1 {
+		t.Errorf("got %+v, want the list's url, title, entries and pages", got)
+	}
+}
+
+func TestScrapeFilmList_ReturnsNonNilErrorWhenMarkupChanged(t *testing.T) {
+
+	for name, page := range map[string]string{
+		"poster list renamed": strings.Replace(listPage, `class="poster-list"`, `class="film-grid"`, 1),
+		"entries renamed":     strings.ReplaceAll(listPage, `class="poster-container"`, `class="griditem"`),
+	} {
+		fetcher := MapFetcher{"https://letterboxd.com/somebody/list/favourite-films-of-2023/": page}
+
+		_, err := ScrapeFilmList(fetcher, "https://letterboxd.com/somebody/list/favourite-films-of-2023/", 0)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestScrapeFilmList_ReturnsEmptyList(t *testing.T) {
+
+	fetcher := MapFetcher{"https://letterboxd.com/somebody/list/empty/": `<ul class="poster-list"></ul>`}
+
+	got, err := ScrapeFilmList(fetcher, "https://letterboxd.com/somebody/list/empty/", 0)
+	if err != nil || len(got.Entries) != 0 {
+		t.Errorf("got %+v, %v, want an empty list", got, err)
+	}
+}
+
+func TestScrapeFilmHtml_ReturnsFilmPage(t *testing.T) {
+
+	fetcher := MapFetcher{
+		"https://letterboxd.com/film/wild-at-heart/": `<html><body>
+			<section class="film-header-group"><h1 class="headline-1 filmtitle"><span>Wild at Heart</span></h1></section>
+			<div id="tabbed-content"><div id="tab-genres"></div></div>
+		</body></html>`,
+	}
+
+	got, err := ScrapeFilmHtml(fetcher, "https://letterboxd.com/film/wild-at-heart/")
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	for _, want := range []string{`<h1 class="headline-1 filmtitle"><span>Wild at Heart</span></h1>`, `<div id="tab-genres">`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %v, want it to contain %v", got, want)
+		}
+	}
+}
+
 func TestParseFilm_ReturnsValidFilm(t *testing.T) {
 
 	got, err := ParseFilm(`
@@ -91,9 +382,9 @@ func TestParseFilm_ReturnsValidFilm(t *testing.T) {
 	}
 
 	want := lb.Film{
-		Title:    "Wild at Heart",
-		Director: "David Lynch",
-		Year:     1990,
+		Title:     "Wild at Heart",
+		Directors: []lb.Contributor{{Name: "David Lynch", Slug: "david-lynch"}},
+		Year:      1990,
 	}
 
 	if !reflect.DeepEqual(got, want) {
@@ -101,6 +392,199 @@ func TestParseFilm_ReturnsValidFilm(t *testing.T) {
 	}
 }
 
+func TestParseFilm_ReturnsEveryDirector(t *testing.T) {
+
+	got, err := ParseFilm(`
+	<div class="details">
+		<h1 class="headline-1 filmtitle">
+		<span class="name js-widont prettify">Fargo</span>
+		</h1>
+		<div class="metablock">
+			<div class="releaseyear">
+				<a href="/films/year/1996/">1996</a>
+			</div>
+			<p class="credits">
+				<span class="introduction">Directed by</span>
+				<span class="directorlist">
+					<a class="contributor" href="/director/joel-coen/">
+					<span class="prettify">Joel Coen</span></a>,
+					<a class="contributor" href="/director/ethan-coen/">
+					<span class="prettify">Ethan Coen</span></a>
+				</span>
+			</p>
+		</div>
+	</div>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := []lb.Contributor{
+		{Name: "Joel Coen", Slug: "joel-coen"},
+		{Name: "Ethan Coen", Slug: "ethan-coen"},
+	}
+
+	if !reflect.DeepEqual(got.Directors, want) {
+		t.Errorf("got %v, want %v", got.Directors, want)
+	}
+}
+
+func TestParseFilm_ReturnsFilmDetails(t *testing.T) {
+
+	got, err := ParseFilm(`
+	<section class="film-header-group">
+		<h1 class="headline-1 filmtitle"><span class="name js-widont prettify">Wild at Heart</span></h1>
+		<div class="releaseyear"><a href="/films/year/1990/">1990</a></div>
+		<h2 class="originalname"><em class="quoted-creative-work-title">Sailor &amp; Lula</em></h2>
+		<p class="credits"><a class="contributor" href="/director/david-lynch/"><span class="prettify">David Lynch</span></a></p>
+	</section>
+	<div id="tabbed-content">
+		<div id="tab-details">
+			<h3><span>Studios</span></h3>
+			<div class="text-sluglist"><p>
+				<a href="/studio/polygram-filmed-entertainment/" class="text-slug">PolyGram Filmed Entertainment</a>
+				<a href="/studio/propaganda-films/" class="text-slug">Propaganda Films</a>
+			</p></div>
+			<h3><span>Country</span></h3>
+			<div class="text-sluglist"><p><a href="/films/country/usa/" class="text-slug">USA</a></p></div>
+			<h3><span>Primary Language</span></h3>
+			<div class="text-sluglist"><p><a href="/films/language/english/" class="text-slug">English</a></p></div>
+			<h3><span>Spoken Languages</span></h3>
+			<div class="text-sluglist"><p>
+				<a href="/films/language/english/" class="text-slug">English</a>
+				<a href="/films/language/spanish/" class="text-slug">Spanish</a>
+			</p></div>
+			<h3><span>Alternative Titles</span></h3>
+			<div class="text-indentedlist"><p>Corazón salvaje</p></div>
+		</div>
+		<div id="tab-genres">
+			<h3><span>Genres</span></h3>
+			<div class="text-sluglist capitalize"><p>
+				<a href="/films/genre/crime/" class="text-slug">Crime</a>
+				<a href="/films/genre/romance/" class="text-slug">Romance</a>
+			</p></div>
+			<h3><span>Themes</span></h3>
+			<div class="text-sluglist capitalize"><p>
+				<a href="/films/theme/crime-drugs-and-gangsters/" class="text-slug">Crime, drugs and gangsters</a>
+				<a href="/film/wild-at-heart/themes/" class="text-slug">Show All…</a>
+			</p></div>
+		</div>
+	</div>
+	<p class="text-link text-footer">125&nbsp;mins &nbsp; More at <a href="http://www.imdb.com/title/tt0100935/maindetails">IMDb</a></p>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := lb.Film{
+		Title:           "Wild at Heart",
+		Directors:       []lb.Contributor{{Name: "David Lynch", Slug: "david-lynch"}},
+		Year:            1990,
+		OriginalTitle:   "Sailor & Lula",
+		Runtime:         125,
+		Genres:          []string{"Crime", "Romance"},
+		Themes:          []string{"Crime, drugs and gangsters"},
+		Countries:       []string{"USA"},
+		PrimaryLanguage: "English",
+		SpokenLanguages: []string{"English", "Spanish"},
+		Studios:         []string{"PolyGram Filmed Entertainment", "Propaganda Films"},
+		ImdbID:          "tt0100935",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseFilm_ReturnsCastAndCrew(t *testing.T) {
+
+	got, err := ParseFilm(`
+	<section class="film-header-group">
+		<h1 class="headline-1 filmtitle"><span class="name js-widont prettify">Wild at Heart</span></h1>
+		<div class="releaseyear"><a href="/films/year/1990/">1990</a></div>
+		<p class="credits"><a class="contributor" href="/director/david-lynch/"><span class="prettify">David Lynch</span></a></p>
+	</section>
+	<div id="tabbed-content">
+		<div id="tab-cast">
+			<div class="cast-list text-sluglist"><p>
+				<a href="/actor/nicolas-cage/" class="text-slug tooltip" data-original-title="Sailor Ripley">Nicolas Cage</a>
+				<a href="/actor/laura-dern/" class="text-slug tooltip" title="Lula Pace Fortune">Laura Dern</a>
+				<a href="#" id="show-cast-overflow" class="text-slug">Show All…</a>
+			</p></div>
+		</div>
+		<div id="tab-crew">
+			<h3><span class="crewrole -full">Director</span><span class="crewrole -short">Director</span></h3>
+			<div class="text-sluglist"><p><a href="/director/david-lynch/" class="text-slug">David Lynch</a></p></div>
+			<h3><span class="crewrole -full">Writer</span><span class="crewrole -short">Writer</span></h3>
+			<div class="text-sluglist"><p><a href="/writer/david-lynch/" class="text-slug">David Lynch</a></p></div>
+			<h3><span class="crewrole -full">Cinematography</span><span class="crewrole -short">DP</span></h3>
+			<div class="text-sluglist"><p><a href="/cinematography/frederick-elmes/" class="text-slug">Frederick Elmes</a></p></div>
+		</div>
+	</div>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	wantCast := []lb.CastMember{
+		{Contributor: lb.Contributor{Name: "Nicolas Cage", Slug: "nicolas-cage"}, Character: "Sailor Ripley", Billing: 1},
+		{Contributor: lb.Contributor{Name: "Laura Dern", Slug: "laura-dern"}, Character: "Lula Pace Fortune", Billing: 2},
+	}
+
+	if !reflect.DeepEqual(got.Cast, wantCast) {
+		t.Errorf("got %v, want %v", got.Cast, wantCast)
+	}
+
+	wantCrew := []lb.CrewMember{
+		{Contributor: lb.Contributor{Name: "David Lynch", Slug: "david-lynch"}, Role: "director"},
+		{Contributor: lb.Contributor{Name: "David Lynch", Slug: "david-lynch"}, Role: "writer"},
+		{Contributor: lb.Contributor{Name: "Frederick Elmes", Slug: "frederick-elmes"}, Role: "cinematography"},
+	}
+
+	if !reflect.DeepEqual(got.Crew, wantCrew) {
+		t.Errorf("got %v, want %v", got.Crew, wantCrew)
+	}
+}
+
+func TestParseFilm_ReturnsIdentifiers(t *testing.T) {
+
+	got, err := ParseFilm(`
+	<html><body class="film backdropped" data-tmdb-type="movie" data-tmdb-id="483">
+		<div class="film-poster" data-film-id="51558" data-film-slug="wild-at-heart"></div>
+		<h1 class="headline-1 filmtitle"><span class="name">Wild at Heart</span></h1>
+		<div class="releaseyear"><a href="/films/year/1990/">1990</a></div>
+		<a class="contributor" href="/director/david-lynch/"><span class="prettify">David Lynch</span></a>
+		<p class="text-link text-footer">
+			125&nbsp;mins &nbsp; More at
+			<a href="http://www.imdb.com/title/tt0100935/maindetails" class="micro-button track-event" data-track-action="IMDb">IMDb</a>
+			<a href="https://www.themoviedb.org/movie/483/" class="micro-button track-event" data-track-action="TMDb">TMDb</a>
+		</p>
+	</body></html>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if got.LetterboxdID != 51558 || got.Slug != "wild-at-heart" || got.TmdbID != 483 || got.TmdbType != "movie" || got.ImdbID != "tt0100935" {
+		t.Errorf("got %v %v %v %v %v, want 51558 wild-at-heart 483 movie tt0100935",
+			got.LetterboxdID, got.Slug, got.TmdbID, got.TmdbType, got.ImdbID)
+	}
+}
+
+func TestParseFilm_ReturnsTmdbIdFromLinkWhenBodyHasNone(t *testing.T) {
+
+	got, err := ParseFilm(`
+	<meta property="og:url" content="https://letterboxd.com/film/twin-peaks-the-return/">
+	<h1 class="headline-1 filmtitle"><span class="name">Twin Peaks: The Return</span></h1>
+	<div class="releaseyear"><a href="/films/year/2017/">2017</a></div>
+	<a class="contributor" href="/director/david-lynch/"><span class="prettify">David Lynch</span></a>
+	<a href="https://www.themoviedb.org/tv/1920/">TMDb</a>`)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if got.Slug != "twin-peaks-the-return" || got.TmdbID != 1920 || got.TmdbType != "tv" || got.ImdbID != "" {
+		t.Errorf("got %v %v %v %v, want twin-peaks-the-return 1920 tv and no imdb id",
+			got.Slug, got.TmdbID, got.TmdbType, got.ImdbID)
+	}
+}
+
 func TestParseFilm_ReturnsNonNilErrorWhenTitleEmpty(t *testing.T) {
 
 	_, err := ParseFilm(`
@@ -269,34 +753,146 @@ func TestParseFilm_ReturnsNonNilErrorWhenDirectorNotPresent(t *testing.T) {
 }
 
 func TestParseUsername(t *testing.T) {
-	got := ParseUsername("https://letterboxd.com/username/list/2023-favs/")
+	got, err := ParseUsername("https://letterboxd.com/username/list/2023-favs/")
 	want := "username"
+	if err != nil || got != want {
+		t.Errorf("got %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestParseUsername_ReturnsErrorForUrlsWithoutMember(t *testing.T) {
+	for _, url := range []string{
+		"https://letterboxd.com",
+		"https://letterboxd.com/",
+		"https://letterboxd.com/film/parasite-2019/",
+		"username/list/2023-favs/",
+		"",
+	} {
+		got, err := ParseUsername(url)
+		if err == nil {
+			t.Errorf("got %q for %q, want error", got, url)
+		}
+	}
+}
+
+// redirectFetcher serves an empty page for each url as if it had been
+// redirected to the mapped url.
+type redirectFetcher map[string]string
+
+func (f redirectFetcher) Fetch(url string) (*Response, error) {
+
+	target, exists := f[url]
+	if !exists {
+		return notFound(url)
+	}
+
+	return &Response{URL: target, StatusCode: http.StatusOK, Body: []byte("<html></html>")}, nil
+}
+
+func TestResolveURL_FollowsShortLinks(t *testing.T) {
+
+	fetcher := redirectFetcher{"https://boxd.it/abc1": "https://letterboxd.com/user/list/favs/"}
+
+	got, err := ResolveURL(fetcher, lb.URL{Kind: lb.ShortURL, Slug: "abc1"}, lb.DefaultBaseURL)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := lb.URL{Kind: lb.ListURL, Username: "user", Slug: "favs"}
 	if got != want {
-		t.Errorf("got %v, want %v", got, want)
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveURL_ReturnsOtherUrlsUnchanged(t *testing.T) {
+
+	url := lb.URL{Kind: lb.ListURL, Username: "user", Slug: "favs"}
+
+	got, err := ResolveURL(MapFetcher{}, url, lb.DefaultBaseURL)
+	if err != nil || got != url {
+		t.Errorf("got %+v, %v, want %+v", got, err, url)
+	}
+}
+
+func TestResolveURL_ReturnsErrorForUnresolvedShortLinks(t *testing.T) {
+
+	fetcher := redirectFetcher{"https://boxd.it/abc1": "https://boxd.it/abc1"}
+
+	_, err := ResolveURL(fetcher, lb.URL{Kind: lb.ShortURL, Slug: "abc1"}, lb.DefaultBaseURL)
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+}
+
+func TestResolveLists_ReturnsFirstPages(t *testing.T) {
+
+	fetcher := redirectFetcher{"https://boxd.it/abc1": "https://letterboxd.com/user/list/favs/page/2/"}
+
+	got, err := ResolveLists(fetcher, []lb.URL{
+		{Kind: lb.ShortURL, Slug: "abc1"},
+		{Kind: lb.ListURL, Username: "other", Slug: "best", Page: 3},
+	}, lb.DefaultBaseURL)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	want := []lb.URL{
+		{Kind: lb.ListURL, Username: "user", Slug: "favs"},
+		{Kind: lb.ListURL, Username: "other", Slug: "best"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveLists_ReturnsErrorForUrlsThatArentLists(t *testing.T) {
+
+	fetcher := redirectFetcher{"https://boxd.it/abc1": "https://letterboxd.com/film/parasite-2019/"}
+
+	_, err := ResolveLists(fetcher, []lb.URL{{Kind: lb.ShortURL, Slug: "abc1"}}, lb.DefaultBaseURL)
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+}
+
+func TestResolveURL_RejectsShortLinksOnOtherSites(t *testing.T) {
+
+	inner := &countingFetcher{Fetcher: redirectFetcher{"https://boxd.it/abc1": "https://letterboxd.com/user/list/favs/"}}
+
+	_, err := ResolveURL(inner, lb.URL{Kind: lb.ShortURL, Slug: "abc1"}, "http://localhost:8080")
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+
+	if inner.Count != 0 {
+		t.Errorf("got %v requests, want %v", inner.Count, 0)
 	}
 }
 
 func TestSumFilmInclusions(t *testing.T) {
-	films := [][]lb.FilmListEntry{
-		{
+	films := []lb.List{
+		{Entries: []lb.FilmListEntry{
 			{Rating: 8, Link: "/film/parasite/"},
 			{Rating: 10, Link: "/film/faust-1926/"},
-		},
-		{
+		}},
+		{Entries: []lb.FilmListEntry{
 			{Rating: 10, Link: "/film/faust-1926/"},
 			{Rating: 8, Link: "/film/wild-at-heart/"},
-		},
-		{
+		}},
+		{Entries: []lb.FilmListEntry{
 			{Rating: 8, Link: "/film/wild-at-heart/"},
 			{Rating: 10, Link: "/film/faust-1926/"},
-		},
+		}},
 	}
 
 	got := SumFilmInclusions(films)
 	want := []lb.FilmListEntry{
-		{Inclusions: 3, Link: "/film/faust-1926/", Rating: 10},
-		{Inclusions: 2, Link: "/film/wild-at-heart/", Rating: 8},
-		{Inclusions: 1, Link: "/film/parasite/", Rating: 8},
+		{Inclusions: 3, Link: "/film/faust-1926/", Rating: 10, OwnerRatings: lb.RatingStats{Count: 3, Mean: 10, Median: 10, Min: 10, Max: 10},
+			Lists: []lb.Inclusion{{Rating: 10}, {Rating: 10}, {Rating: 10}}},
+		{Inclusions: 2, Link: "/film/wild-at-heart/", Rating: 8, OwnerRatings: lb.RatingStats{Count: 2, Mean: 8, Median: 8, Min: 8, Max: 8},
+			Lists: []lb.Inclusion{{Rating: 8}, {Rating: 8}}},
+		{Inclusions: 1, Link: "/film/parasite/", Rating: 8, OwnerRatings: lb.RatingStats{Count: 1, Mean: 8, Median: 8, Min: 8, Max: 8},
+			Lists: []lb.Inclusion{{Rating: 8}}},
 	}
 
 	if !reflect.DeepEqual(got, want) {
@@ -304,21 +900,160 @@ func TestSumFilmInclusions(t *testing.T) {
 	}
 }
 
+func TestSumFilmInclusions_SummarisesOwnerRatings(t *testing.T) {
+	films := []lb.List{
+		{Entries: []lb.FilmListEntry{{Rating: 4, Link: "/film/parasite/"}}},
+		{Entries: []lb.FilmListEntry{{Rating: lb.Unrated, Link: "/film/parasite/"}}},
+		{Entries: []lb.FilmListEntry{{Rating: 9, Link: "/film/parasite/"}}},
+		{Entries: []lb.FilmListEntry{{Rating: 6, Link: "/film/parasite/"}}},
+		{Entries: []lb.FilmListEntry{{Rating: 10, Link: "/film/parasite/"}}},
+		{Entries: []lb.FilmListEntry{{Rating: lb.Unrated, Link: "/film/nowhere/"}}},
+	}
+
+	got := SumFilmInclusions(films)
+	want := []lb.FilmListEntry{
+		{Inclusions: 5, Link: "/film/parasite/", Rating: 7, OwnerRatings: lb.RatingStats{Count: 4, Mean: 7.25, Median: 7.5, Min: 4, Max: 10},
+			Lists: []lb.Inclusion{{Rating: 4}, {Rating: lb.Unrated}, {Rating: 9}, {Rating: 6}, {Rating: 10}}},
+		{Inclusions: 1, Link: "/film/nowhere/", Rating: lb.Unrated, OwnerRatings: lb.RatingStats{},
+			Lists: []lb.Inclusion{{Rating: lb.Unrated}}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSumFilmInclusions_KeepsBestRankedPosition(t *testing.T) {
+	films := []lb.List{
+		{Ranked: true, Entries: []lb.FilmListEntry{
+			{Link: "/film/parasite/", Position: 1},
+			{Link: "/film/faust-1926/", Position: 2},
+		}},
+		{Ranked: false, Entries: []lb.FilmListEntry{
+			{Link: "/film/wild-at-heart/", Position: 1},
+			{Link: "/film/faust-1926/", Position: 2},
+		}},
+		{Ranked: true, Entries: []lb.FilmListEntry{
+			{Link: "/film/faust-1926/", Position: 1},
+			{Link: "/film/parasite/", Position: 2},
+		}},
+	}
+
+	got := SumFilmInclusions(films)
+	want := []lb.FilmListEntry{
+		{Inclusions: 3, Link: "/film/faust-1926/", Position: 1,
+			Lists: []lb.Inclusion{{Position: 2, Ranked: true}, {Position: 2}, {Position: 1, Ranked: true}}},
+		{Inclusions: 2, Link: "/film/parasite/", Position: 1,
+			Lists: []lb.Inclusion{{Position: 1, Ranked: true}, {Position: 2, Ranked: true}}},
+		{Inclusions: 1, Link: "/film/wild-at-heart/", Position: 0,
+			Lists: []lb.Inclusion{{Position: 1}}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSumFilmInclusions_RecordsIncludingLists(t *testing.T) {
+	films := []lb.List{
+		{URL: "https://letterboxd.com/alice/list/favs/", Title: "Favs", Owner: "alice", Ranked: true, Entries: []lb.FilmListEntry{
+			{Link: "/film/parasite/", Position: 1, Rating: 9, UserName: "alice"},
+		}},
+		{URL: "https://letterboxd.com/bob/list/watched/", Title: "Watched", Owner: "bob", Entries: []lb.FilmListEntry{
+			{Link: "/film/faust-1926/", Position: 1, UserName: "bob"},
+			{Link: "/film/parasite/", Position: 2, Rating: 7, UserName: "bob"},
+		}},
+	}
+
+	entry := SumFilmInclusions(films)[0]
+	want := []lb.Inclusion{
+		{ListURL: "https://letterboxd.com/alice/list/favs/", ListTitle: "Favs", Owner: "alice", Position: 1, Ranked: true, Rating: 9},
+		{ListURL: "https://letterboxd.com/bob/list/watched/", ListTitle: "Watched", Owner: "bob", Position: 2, Rating: 7},
+	}
+
+	if !reflect.DeepEqual(entry.Lists, want) {
+		t.Errorf("got %v, want %v", entry.Lists, want)
+	}
+
+	if got := MergeListEntry(lb.Film{}, entry).UserName; got != "alice" {
+		t.Errorf("got %v, want %v", got, "alice")
+	}
+}
+
 func TestSumDirectorInclusions(t *testing.T) {
+	araki := []lb.Contributor{{Name: "Gregg Araki", Slug: "gregg-araki"}}
+	lynch := []lb.Contributor{{Name: "David Lynch", Slug: "david-lynch"}}
+	cronenberg := []lb.Contributor{{Name: "David Cronenburg", Slug: "david-cronenberg"}}
+
+	films := []lb.Film{
+		{Rating: 8, Link: "/film/totally-fucked-up/", Directors: araki},
+		{Rating: 8, Link: "/film/nowhere/", Directors: araki},
+		{Rating: 10, Link: "/film/wild-at-heart/", Directors: lynch},
+		{Rating: 8, Link: "/film/inland-empire/", Directors: lynch},
+		{Rating: 8, Link: "/film/eraser-head/", Directors: lynch},
+		{Rating: 8, Link: "/film/dead-ringers/", Directors: cronenberg},
+	}
+
+	got := SumDirectorInclusions(films)
+	want := []lb.Director{
+		{Name: "David Lynch", Slug: "david-lynch", Inclusions: 3},
+		{Name: "Gregg Araki", Slug: "gregg-araki", Inclusions: 2},
+		{Name: "David Cronenburg", Slug: "david-cronenberg", Inclusions: 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSumDirectorInclusions_CreditsEveryCoDirector(t *testing.T) {
+	joel := lb.Contributor{Name: "Joel Coen", Slug: "joel-coen"}
+	ethan := lb.Contributor{Name: "Ethan Coen", Slug: "ethan-coen"}
+
 	films := []lb.Film{
-		{Rating: 8, Link: "/film/totally-fucked-up/", Director: "Gregg Araki"},
-		{Rating: 8, Link: "/film/nowhere/", Director: "Gregg Araki"},
-		{Rating: 10, Link: "/film/wild-at-heart/", Director: "David Lynch"},
-		{Rating: 8, Link: "/film/inland-empire/", Director: "David Lynch"},
-		{Rating: 8, Link: "/film/eraser-head/", Director: "David Lynch"},
-		{Rating: 8, Link: "/film/dead-ringers/", Director: "David Cronenburg"},
+		{Link: "/film/fargo/", Directors: []lb.Contributor{joel, ethan}},
+		{Link: "/film/no-country-for-old-men/", Directors: []lb.Contributor{joel, ethan}},
+		{Link: "/film/the-tragedy-of-macbeth-2021/", Directors: []lb.Contributor{joel}},
 	}
 
 	got := SumDirectorInclusions(films)
 	want := []lb.Director{
-		{Name: "David Lynch", Inclusions: 3},
-		{Name: "Gregg Araki", Inclusions: 2},
-		{Name: "David Cronenburg", Inclusions: 1},
+		{Name: "Joel Coen", Slug: "joel-coen", Inclusions: 3},
+		{Name: "Ethan Coen", Slug: "ethan-coen", Inclusions: 2},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSumPeopleInclusions(t *testing.T) {
+	cage := lb.Contributor{Name: "Nicolas Cage", Slug: "nicolas-cage"}
+	lynch := lb.Contributor{Name: "David Lynch", Slug: "david-lynch"}
+
+	films := []lb.Film{
+		{
+			Link: "/film/wild-at-heart/",
+			Cast: []lb.CastMember{{Contributor: cage, Billing: 1}},
+			Crew: []lb.CrewMember{{Contributor: lynch, Role: "director"}, {Contributor: lynch, Role: "writer"}},
+		},
+		{
+			Link: "/film/twin-peaks-fire-walk-with-me/",
+			Cast: []lb.CastMember{{Contributor: lynch, Character: "Gordon Cole", Billing: 1}},
+			Crew: []lb.CrewMember{{Contributor: lynch, Role: "director"}, {Contributor: lynch, Role: "writer"}, {Contributor: lynch, Role: "writer"}},
+		},
+		{
+			Link: "/film/face-off/",
+			Cast: []lb.CastMember{{Contributor: cage, Billing: 2}},
+		},
+	}
+
+	got := SumPeopleInclusions(films)
+	want := []lb.Person{
+		{Name: "Nicolas Cage", Slug: "nicolas-cage", Role: "actor", Inclusions: 2},
+		{Name: "David Lynch", Slug: "david-lynch", Role: "director", Inclusions: 2},
+		{Name: "David Lynch", Slug: "david-lynch", Role: "writer", Inclusions: 2},
+		{Name: "David Lynch", Slug: "david-lynch", Role: "actor", Inclusions: 1},
 	}
 
 	if !reflect.DeepEqual(got, want) {
@@ -0,0 +1,194 @@
+package scraper
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andybalholm/cascadia"
+)
+
+// SelectorsVersion is the version of the selectors file format this release
+// reads. It changes whenever selectors are added, removed or change meaning.
+const SelectorsVersion = 1
+
+// Selectors are the CSS selectors, and the attributes of the elements they
+// select, that the parsers find things in Letterboxd's markup with. Fields
+// whose names end in Attr are attribute names rather than selectors.
+type Selectors struct {
+	Version int `json:"version"`
+
+	PosterList          string `json:"poster_list"`
+	NextPage            string `json:"next_page"`
+	ListEntry           string `json:"list_entry"`
+	ListEntryPoster     string `json:"list_entry_poster"`
+	ListEntryLinkAttr   string `json:"list_entry_link_attr"`
+	ListEntryRatingAttr string `json:"list_entry_rating_attr"`
+	ListEntryNumber     string `json:"list_entry_number"`
+
+	ListTitle       string `json:"list_title"`
+	ListTitleMeta   string `json:"list_title_meta"`
+	ListOwner       string `json:"list_owner"`
+	ListDescription string `json:"list_description"`
+	ListTags        string `json:"list_tags"`
+	ListDates       string `json:"list_dates"`
+	ListDateAttr    string `json:"list_date_attr"`
+	ListLikes       string `json:"list_likes"`
+	ListComments    string `json:"list_comments"`
+	ListSummaryMeta string `json:"list_summary_meta"`
+	MetaContentAttr string `json:"meta_content_attr"`
+
+	FilmTitle       string `json:"film_title"`
+	FilmTitleName   string `json:"film_title_name"`
+	ReleaseYear     string `json:"release_year"`
+	ReleaseYearLink string `json:"release_year_link"`
+	Director        string `json:"director"`
+	DirectorName    string `json:"director_name"`
+	OriginalTitle   string `json:"original_title"`
+	FilmFooter      string `json:"film_footer"`
+	FilmPoster      string `json:"film_poster"`
+	FilmIDAttr      string `json:"film_id_attr"`
+	FilmSlugAttr    string `json:"film_slug_attr"`
+	FilmURLMeta     string `json:"film_url_meta"`
+	TmdbIDs         string `json:"tmdb_ids"`
+	TmdbIDAttr      string `json:"tmdb_id_attr"`
+	TmdbTypeAttr    string `json:"tmdb_type_attr"`
+	TmdbLink        string `json:"tmdb_link"`
+	ImdbLink        string `json:"imdb_link"`
+
+	GenresTab      string `json:"genres_tab"`
+	DetailsTab     string `json:"details_tab"`
+	TabHeading     string `json:"tab_heading"`
+	TabHeadingName string `json:"tab_heading_name"`
+	TabSection     string `json:"tab_section"`
+	TabLink        string `json:"tab_link"`
+	Cast           string `json:"cast"`
+	Crew           string `json:"crew"`
+
+	AverageRating    string `json:"average_rating"`
+	HistogramBar     string `json:"histogram_bar"`
+	HistogramBarLink string `json:"histogram_bar_link"`
+	Watches          string `json:"watches"`
+	Lists            string `json:"lists"`
+	Likes            string `json:"likes"`
+	StructuredData   string `json:"structured_data"`
+	TooltipAttr      string `json:"tooltip_attr"`
+}
+
+var attributeNameRegexp = regexp.MustCompile(`^[A-Za-z_:][-A-Za-z0-9_:.]*$`)
+
+//go:embed selectors.json
+var defaultSelectorsFile []byte
+
+var selectors atomic.Pointer[Selectors]
+
+func init() {
+	defaults, err := parseSelectors(defaultSelectorsFile, Selectors{})
+	if err != nil {
+		panic("invalid default selectors: " + err.Error())
+	}
+
+	selectors.Store(&defaults)
+}
+
+// DefaultSelectors returns the selectors built into this release.
+func DefaultSelectors() Selectors {
+	defaults, _ := parseSelectors(defaultSelectorsFile, Selectors{})
+	return defaults
+}
+
+// CurrentSelectors returns the selectors the parsers are using.
+func CurrentSelectors() Selectors {
+	return *selectors.Load()
+}
+
+// SetSelectors validates s and has the parsers use it from then on.
+func SetSelectors(s Selectors) error {
+
+	err := s.Validate()
+	if err != nil {
+		return err
+	}
+
+	selectors.Store(&s)
+
+	return nil
+}
+
+// LoadSelectors reads the selectors file at path over the defaults, so that
+// the file only needs the selectors it changes, and validates the result.
+func LoadSelectors(path string) (Selectors, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Selectors{}, err
+	}
+
+	loaded, err := parseSelectors(data, DefaultSelectors())
+	if err != nil {
+		return Selectors{}, fmt.Errorf("error loading selectors from %s: %w", path, err)
+	}
+
+	return loaded, nil
+}
+
+// parseSelectors decodes a selectors file over base and validates the result.
+func parseSelectors(data []byte, base Selectors) (Selectors, error) {
+
+	base.Version = 0
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&base)
+	if err != nil {
+		return Selectors{}, err
+	}
+
+	return base, base.Validate()
+}
+
+// Validate checks that s is for this release and that every selector compiles
+// and every attribute name is valid.
+func (s Selectors) Validate() error {
+
+	if s.Version != SelectorsVersion {
+		return fmt.Errorf("selectors are version %d, expected version %d", s.Version, SelectorsVersion)
+	}
+
+	var errs []error
+
+	value := reflect.ValueOf(s)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		text := value.Field(i).String()
+
+		switch {
+		case text == "":
+			errs = append(errs, fmt.Errorf("%s is empty", name))
+		case strings.HasSuffix(field.Name, "Attr"):
+			if !attributeNameRegexp.MatchString(text) {
+				errs = append(errs, fmt.Errorf("%s: invalid attribute name %q", name, text))
+			}
+		default:
+			_, err := cascadia.ParseGroup(text)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid selector %q: %w", name, text, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
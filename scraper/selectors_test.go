@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultSelectors_AreValid(t *testing.T) {
+
+	err := DefaultSelectors().Validate()
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+}
+
+func TestLoadSelectors_OverridesDefaults(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "selectors.json")
+	os.WriteFile(path, []byte(`{"version": 1, "film_title": "h1.headline-1"}`), 0o644)
+
+	got, err := LoadSelectors(path)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	want := DefaultSelectors()
+	want.FilmTitle = "h1.headline-1"
+
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadSelectors_RejectsInvalidFiles(t *testing.T) {
+
+	tests := map[string]struct {
+		file string
+		want string
+	}{
+		"WrongVersion":      {`{"version": 2}`, "version 2"},
+		"MissingVersion":    {`{"film_title": "h1"}`, "version 0"},
+		"InvalidSelector":   {`{"version": 1, "film_title": "h1["}`, "film_title"},
+		"EmptySelector":     {`{"version": 1, "director": ""}`, "director is empty"},
+		"InvalidAttribute":  {`{"version": 1, "list_entry_rating_attr": "data owner"}`, "list_entry_rating_attr"},
+		"UnknownSelector":   {`{"version": 1, "film_titel": "h1"}`, "film_titel"},
+		"MalformedJSON":     {`{"version": 1,`, "unexpected EOF"},
+		"SeveralInvalid":    {`{"version": 1, "cast": "[", "crew": "["}`, "crew"},
+		"AttributeSelector": {`{"version": 1, "list_entry_link_attr": "[data-link]"}`, "list_entry_link_attr"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			path := filepath.Join(t.TempDir(), "selectors.json")
+			os.WriteFile(path, []byte(test.file), 0o644)
+
+			_, err := LoadSelectors(path)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestSetSelectors_RejectsInvalidSelectors(t *testing.T) {
+
+	selectors := DefaultSelectors()
+	selectors.PosterList = "ul["
+
+	err := SetSelectors(selectors)
+	if err == nil {
+		t.Fatalf("got %v, want an error", err)
+	}
+
+	if CurrentSelectors() != DefaultSelectors() {
+		t.Errorf("got %+v, want the defaults", CurrentSelectors())
+	}
+}
+
+func TestSetSelectors_ChangesParsing(t *testing.T) {
+
+	t.Cleanup(func() { SetSelectors(DefaultSelectors()) })
+
+	selectors := DefaultSelectors()
+	selectors.ListEntry = "li.griditem"
+	selectors.ListEntryPoster = "div.react-component"
+
+	err := SetSelectors(selectors)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	got, err := ParseFilmList(`<ul class="poster-list">
+		<li class="griditem" data-owner-rating="8">
+			<div class="react-component" data-target-link="/film/parasite-2019/"></div>
+			<p class="list-number">1</p>
+		</li>
+	</ul>`)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if len(got) != 1 || got[0].Link != "/film/parasite-2019/" || got[0].Position != 1 {
+		t.Errorf("got %+v, want /film/parasite-2019/ at position 1", got)
+	}
+}
+
+func TestSetSelectors_ChangesAttributesRead(t *testing.T) {
+
+	t.Cleanup(func() { SetSelectors(DefaultSelectors()) })
+
+	selectors := DefaultSelectors()
+	selectors.FilmPoster = "div.poster"
+	selectors.FilmIDAttr = "data-id"
+	selectors.FilmSlugAttr = "data-slug"
+
+	err := SetSelectors(selectors)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	got, err := ParseFilm(`<html><body>
+		<h1 class="filmtitle"><span>Wild at Heart</span></h1>
+		<div class="releaseyear"><a href="/films/year/1990/">1990</a></div>
+		<a class="contributor" href="/director/david-lynch/"><span>David Lynch</span></a>
+		<div class="poster" data-id="48012" data-slug="wild-at-heart"></div>
+	</body></html>`)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if got.LetterboxdID != 48012 || got.Slug != "wild-at-heart" {
+		t.Errorf("got %v %q, want %v %q", got.LetterboxdID, got.Slug, 48012, "wild-at-heart")
+	}
+}
@@ -0,0 +1,410 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WARCWriter writes HTTP exchanges as WARC 1.1 records, a request record and a
+// response record for each. If Compress is set each record is written as its
+// own gzip member, as is usual for .warc.gz files.
+type WARCWriter struct {
+	Writer   io.Writer
+	Compress bool
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewWARCWriter returns a WARCWriter writing to w, starting with a warcinfo
+// record naming software as the tool that made the archive.
+func NewWARCWriter(w io.Writer, compress bool, software string) (*WARCWriter, error) {
+
+	writer := &WARCWriter{Writer: w, Compress: compress}
+
+	info := "software: " + software + "\r\nformat: WARC File Format 1.1\r\n"
+
+	err := writer.writeRecord(warcHeader{
+		"WARC-Type":    "warcinfo",
+		"Content-Type": "application/warc-fields",
+	}, []byte(info))
+	if err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// responseURIField names the url a response came from when it differs from the
+// url requested, such as a short link's page, as the fetchers follow redirects
+// and the redirect itself isn't seen.
+const responseURIField = "LB-Scrape-Response-URI"
+
+// WriteExchange writes the request for url and the response to it, dated when
+// the response was fetched. If the response came from another url after
+// redirects it's recorded under url along with the url it came from, so that
+// the archive serves it as it was fetched.
+func (w *WARCWriter) WriteExchange(url string, response *Response) error {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return err
+	}
+
+	fetchedAt := response.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = w.clock()
+	}
+
+	date := fetchedAt.UTC().Format(time.RFC3339)
+
+	responseID, err := newRecordID()
+	if err != nil {
+		return err
+	}
+
+	request := "GET " + u.RequestURI() + " HTTP/1.1\r\nHost: " + u.Host + "\r\n\r\n"
+
+	err = w.writeRecord(warcHeader{
+		"WARC-Type":          "request",
+		"WARC-Date":          date,
+		"WARC-Target-URI":    url,
+		"WARC-Concurrent-To": responseID,
+		"Content-Type":       "application/http;msgtype=request",
+	}, []byte(request))
+	if err != nil {
+		return err
+	}
+
+	header := warcHeader{
+		"WARC-Type":           "response",
+		"WARC-Record-ID":      responseID,
+		"WARC-Date":           date,
+		"WARC-Target-URI":     url,
+		"WARC-Payload-Digest": digest(response.Body),
+		"Content-Type":        "application/http;msgtype=response",
+	}
+	if response.URL != "" && response.URL != url {
+		header[responseURIField] = response.URL
+	}
+
+	return w.writeRecord(header, httpResponseBlock(response))
+}
+
+func (w *WARCWriter) clock() time.Time {
+	if w.now == nil {
+		return time.Now()
+	}
+
+	return w.now()
+}
+
+// warcHeader holds the named fields of a WARC record header.
+type warcHeader map[string]string
+
+// warcHeaderOrder is the order well known fields are written in. Other fields
+// follow in alphabetical order.
+var warcHeaderOrder = []string{"WARC-Type", "WARC-Record-ID", "WARC-Date", "WARC-Target-URI"}
+
+func (w *WARCWriter) writeRecord(header warcHeader, block []byte) error {
+
+	if header["WARC-Record-ID"] == "" {
+		id, err := newRecordID()
+		if err != nil {
+			return err
+		}
+		header["WARC-Record-ID"] = id
+	}
+	if header["WARC-Date"] == "" {
+		header["WARC-Date"] = w.clock().UTC().Format(time.RFC3339)
+	}
+	header["WARC-Block-Digest"] = digest(block)
+	header["Content-Length"] = strconv.Itoa(len(block))
+
+	var names []string
+	for name := range header {
+		known := false
+		for _, ordered := range warcHeaderOrder {
+			known = known || name == ordered
+		}
+		if !known {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	record := bytes.Buffer{}
+	record.WriteString("WARC/1.1\r\n")
+	for _, name := range append(append([]string{}, warcHeaderOrder...), names...) {
+		if value, exists := header[name]; exists {
+			record.WriteString(name + ": " + value + "\r\n")
+		}
+	}
+	record.WriteString("\r\n")
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	if !w.Compress {
+		_, err := w.Writer.Write(record.Bytes())
+		return err
+	}
+
+	zw := gzip.NewWriter(w.Writer)
+	_, err := zw.Write(record.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// httpResponseBlock rebuilds the HTTP response message for response. The body
+// has already been decoded, so any transfer or content encoding is dropped and
+// the Content-Length set to match it.
+func httpResponseBlock(response *Response) []byte {
+
+	header := response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Del("Transfer-Encoding")
+	header.Del("Content-Encoding")
+	header.Set("Content-Length", strconv.Itoa(len(response.Body)))
+
+	block := bytes.Buffer{}
+	fmt.Fprintf(&block, "HTTP/1.1 %d %s\r\n", response.StatusCode, http.StatusText(response.StatusCode))
+	header.Write(&block)
+	block.WriteString("\r\n")
+	block.Write(response.Body)
+
+	return block.Bytes()
+}
+
+// digest returns the base32 encoded SHA-1 digest WARC records are labelled with.
+func digest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// newRecordID returns a random version 4 UUID as a WARC record id.
+func newRecordID() (string, error) {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return "", fmt.Errorf("error generating warc record id: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// WARCFetcher wraps a Fetcher, writing every response it returns, whatever its
+// status, to Writer. Requests that fail without a response aren't written.
+type WARCFetcher struct {
+	Fetcher Fetcher
+	Writer  *WARCWriter
+}
+
+func (f *WARCFetcher) Fetch(url string) (*Response, error) {
+
+	response, err := f.Fetcher.Fetch(url)
+	if response == nil {
+		return response, err
+	}
+
+	writeErr := f.Writer.WriteExchange(url, response)
+	if writeErr != nil {
+		return response, errors.Join(err, fmt.Errorf("error archiving %s: %w", url, writeErr))
+	}
+
+	return response, err
+}
+
+// WARCArchive serves the responses in a WARC file. When a url was fetched more
+// than once the last response is served, as it's the one the scraper went on
+// with after any retries.
+type WARCArchive struct {
+	responses map[string]*Response
+	urls      []string
+}
+
+// OpenWARCArchive reads the WARC file at path, which may be gzip compressed.
+func OpenWARCArchive(path string) (*WARCArchive, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadWARCArchive(file)
+}
+
+// ReadWARCArchive reads the response records of a WARC file from r, which may
+// be gzip compressed. Other records are skipped.
+func ReadWARCArchive(r io.Reader) (*WARCArchive, error) {
+
+	reader := bufio.NewReader(r)
+
+	magic, err := reader.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		reader = bufio.NewReader(zr)
+	}
+
+	archive := &WARCArchive{responses: map[string]*Response{}}
+
+	for {
+		header, block, err := readWARCRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header["warc-type"] != "response" || !strings.HasPrefix(header["content-type"], "application/http") {
+			continue
+		}
+
+		url := header["warc-target-uri"]
+
+		responseURL := url
+		if uri := header[strings.ToLower(responseURIField)]; uri != "" {
+			responseURL = uri
+		}
+
+		response, err := parseHTTPResponseBlock(responseURL, block)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response for %s from warc: %w", url, err)
+		}
+
+		// A response without a valid WARC-Date is served as if just fetched.
+		fetchedAt, err := time.Parse(time.RFC3339, header["warc-date"])
+		if err == nil {
+			response.FetchedAt = fetchedAt
+		}
+
+		if _, exists := archive.responses[url]; !exists {
+			archive.urls = append(archive.urls, url)
+		}
+		archive.responses[url] = response
+	}
+
+	return archive, nil
+}
+
+func (a *WARCArchive) Fetch(url string) (*Response, error) {
+
+	response, exists := a.responses[url]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s in the warc archive", ErrNotStored, url)
+	}
+
+	return response, checkStatus(response)
+}
+
+// URLs returns the url of every response in the archive in the order they
+// were first archived.
+func (a *WARCArchive) URLs() []string {
+	return append([]string{}, a.urls...)
+}
+
+// readWARCRecord reads the next record from reader, returning io.EOF if there
+// are no more. Field names are case-insensitive, so the header's are returned
+// in lower case.
+func readWARCRecord(reader *bufio.Reader) (warcHeader, []byte, error) {
+
+	var version string
+	for version == "" {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && strings.TrimSpace(line) == "" {
+			return nil, nil, io.EOF
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		version = strings.TrimSpace(line)
+	}
+
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, nil, fmt.Errorf("error reading warc record: unexpected %q", version)
+	}
+
+	header := warcHeader{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading warc record header: %w", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, nil, fmt.Errorf("error reading warc record header: unexpected %q", line)
+		}
+		header[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+
+	length, err := strconv.Atoi(header["content-length"])
+	if err != nil || length < 0 {
+		return nil, nil, errors.New("error reading warc record: invalid Content-Length")
+	}
+
+	block := make([]byte, length)
+	_, err = io.ReadFull(reader, block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading warc record block: %w", err)
+	}
+
+	return header, block, nil
+}
+
+// parseHTTPResponseBlock parses the HTTP response message of a response record.
+func parseHTTPResponseBlock(url string, block []byte) (*Response, error) {
+
+	httpResponse, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	body, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		URL:        url,
+		StatusCode: httpResponse.StatusCode,
+		Header:     httpResponse.Header,
+		Body:       body,
+	}, nil
+}
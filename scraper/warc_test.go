@@ -0,0 +1,172 @@
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	lb "github.com/ellis-vester/lb-scrape/letterboxd"
+)
+
+func TestWARCFetcher_ArchivesResponsesForParsing(t *testing.T) {
+
+	for _, compress := range []bool{false, true} {
+		archived := bytes.Buffer{}
+
+		writer, err := NewWARCWriter(&archived, compress, "lb-scrape test")
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		fetcher := &WARCFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/wild-at-heart/": filmPage("Wild at Heart")}, Writer: writer}
+
+		film, err := ScrapeFilm(fetcher, "https://letterboxd.com/film/wild-at-heart/")
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		err = ScrapeFilmCommunity(fetcher, "https://letterboxd.com/film/wild-at-heart/", &film)
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		archive, err := ReadWARCArchive(&archived)
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		film, err = ScrapeFilm(archive, "https://letterboxd.com/film/wild-at-heart/")
+		if err != nil || film.Title != "Wild at Heart" {
+			t.Errorf("got %q, %v, want %q", film.Title, err, "Wild at Heart")
+		}
+
+		want := []string{
+			"https://letterboxd.com/film/wild-at-heart/",
+			"https://letterboxd.com/csi/film/wild-at-heart/rating-histogram/",
+			"https://letterboxd.com/csi/film/wild-at-heart/stats/",
+		}
+		if got := archive.URLs(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWARCFetcher_DatesCachedResponsesWhenTheyWereFetched(t *testing.T) {
+
+	fetchedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	dir := t.TempDir()
+	cache := &CacheFetcher{Fetcher: MapFetcher{"https://letterboxd.com/film/parasite/": "<html></html>"}, Dir: dir, now: func() time.Time { return fetchedAt }}
+	cache.Fetch("https://letterboxd.com/film/parasite/")
+
+	archived := bytes.Buffer{}
+	writer, _ := NewWARCWriter(&archived, false, "lb-scrape test")
+	writer.now = func() time.Time { return fetchedAt.Add(time.Hour) }
+
+	fetcher := &WARCFetcher{Fetcher: &CacheFetcher{Fetcher: MapFetcher{}, Dir: dir}, Writer: writer}
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+
+	archive, err := ReadWARCArchive(&archived)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	got, err := archive.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if !got.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("got %v, want %v", got.FetchedAt, fetchedAt)
+	}
+}
+
+func TestWARCArchive_ServesLastResponseWithItsStatus(t *testing.T) {
+
+	archived := bytes.Buffer{}
+	writer, _ := NewWARCWriter(&archived, false, "lb-scrape test")
+
+	fetcher := &WARCFetcher{Fetcher: &scriptedFetcher{Statuses: []int{429, 200}}, Writer: writer}
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	fetcher.Fetch("https://letterboxd.com/film/parasite/")
+	fetcher.Fetch("https://letterboxd.com/film/past-lives/")
+
+	archive, err := ReadWARCArchive(&archived)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	_, err = archive.Fetch("https://letterboxd.com/film/parasite/")
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	_, err = archive.Fetch("https://letterboxd.com/film/anatomy-of-a-fall/")
+	var statusErr *StatusError
+	if err == nil || errors.As(err, &statusErr) {
+		t.Errorf("got %v, want an error that isn't a status", err)
+	}
+}
+
+func TestWARCArchive_ResolvesArchivedShortLinks(t *testing.T) {
+
+	archived := bytes.Buffer{}
+	writer, _ := NewWARCWriter(&archived, true, "lb-scrape test")
+
+	fetcher := &WARCFetcher{Fetcher: redirectFetcher{"https://boxd.it/abc1": "https://letterboxd.com/user/list/favs/"}, Writer: writer}
+
+	_, err := ResolveURL(fetcher, lb.URL{Kind: lb.ShortURL, Slug: "abc1"}, lb.DefaultBaseURL)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	archive, err := ReadWARCArchive(&archived)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	got, err := ResolveURL(archive, lb.URL{Kind: lb.ShortURL, Slug: "abc1"}, lb.DefaultBaseURL)
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	want := lb.URL{Kind: lb.ListURL, Username: "user", Slug: "favs"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestReadWARCArchive_ReadsChunkedResponses(t *testing.T) {
+
+	block := "HTTP/1.1 404 Not Found\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n"
+	warc := "WARC/1.0\r\n" +
+		"warc-type: response\r\n" +
+		"WARC-Target-URI: https://letterboxd.com/film/nowhere/\r\n" +
+		"Content-Type: application/http; msgtype=response\r\n" +
+		"Content-Length: " + strconv.Itoa(len(block)) + "\r\n\r\n" +
+		block + "\r\n\r\n"
+
+	archive, err := ReadWARCArchive(strings.NewReader(warc))
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	response, err := archive.Fetch("https://letterboxd.com/film/nowhere/")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || string(response.Body) != "hello" {
+		t.Errorf("got %+v, %v, want a 404 with body hello", response, err)
+	}
+}
+
+func TestReadWARCArchive_ReturnsErrorForTruncatedRecords(t *testing.T) {
+
+	_, err := ReadWARCArchive(strings.NewReader("WARC/1.1\r\nWARC-Type: response\r\nContent-Length: 100\r\n\r\nHTTP/1.1 200 OK\r\n"))
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+}